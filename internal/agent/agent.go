@@ -0,0 +1,252 @@
+// Package agent runs this binary as a remote probe: it registers with the
+// server, pulls the devices and checks assigned to it, runs them locally
+// and pushes the results back.
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/scheduler"
+	"github.com/simonaditiabbp/netmon-backend/internal/usecase"
+)
+
+type Config struct {
+	Server       string        // base URL of the backend
+	Token        string        // API token of the agent
+	SyncInterval time.Duration // how often assignments are pulled
+	PushInterval time.Duration // how often results are pushed
+	Probe        scheduler.Config
+}
+
+// ConfigFromEnv reads AGENT_SERVER, AGENT_TOKEN, AGENT_SYNC_INTERVAL
+// (default 1m) and AGENT_PUSH_INTERVAL (default 5s), and the PROBE_*
+// settings of the scheduler. A .env file is used when there is one.
+func ConfigFromEnv() Config {
+	_ = godotenv.Load()
+	cfg := Config{
+		Server:       strings.TrimRight(os.Getenv("AGENT_SERVER"), "/"),
+		Token:        os.Getenv("AGENT_TOKEN"),
+		SyncInterval: time.Minute,
+		PushInterval: 5 * time.Second,
+		Probe:        scheduler.ConfigFromEnv(),
+	}
+	if v, err := time.ParseDuration(os.Getenv("AGENT_SYNC_INTERVAL")); err == nil && v > 0 {
+		cfg.SyncInterval = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("AGENT_PUSH_INTERVAL")); err == nil && v > 0 {
+		cfg.PushInterval = v
+	}
+	return cfg
+}
+
+type Agent struct {
+	cfg     Config
+	client  *http.Client
+	probers map[string]usecase.Prober
+
+	mu          sync.Mutex
+	assignments map[uint]usecase.AgentAssignment
+	// pending holds the latest report of every device not pushed yet. A
+	// newer report replaces an older one, so results checked while the
+	// server is unreachable are not all kept.
+	pending map[uint]usecase.AgentReport
+}
+
+func New(cfg Config) *Agent {
+	a := &Agent{
+		cfg:         cfg,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		probers:     make(map[string]usecase.Prober),
+		assignments: make(map[uint]usecase.AgentAssignment),
+		pending:     make(map[uint]usecase.AgentReport),
+	}
+	for _, p := range usecase.DefaultProbers() {
+		a.probers[p.Type()] = p
+	}
+	a.cfg.Probe.Name = "Agent"
+	return a
+}
+
+// Run registers the agent and probes its devices until ctx is cancelled.
+// Failing requests are retried at the next interval.
+func (a *Agent) Run(ctx context.Context) error {
+	if a.cfg.Server == "" || a.cfg.Token == "" {
+		return errors.New("AGENT_SERVER and AGENT_TOKEN are required")
+	}
+	for {
+		err := a.register(ctx)
+		if err == nil {
+			break
+		}
+		log.Printf("Error registering agent: %v", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(a.cfg.SyncInterval):
+		}
+	}
+	if err := a.sync(ctx); err != nil {
+		log.Printf("Error fetching assignments: %v", err)
+	}
+
+	go scheduler.New(a.cfg.Probe, a.devices, a.check).Run(ctx)
+
+	syncTicker := time.NewTicker(a.cfg.SyncInterval)
+	defer syncTicker.Stop()
+	pushTicker := time.NewTicker(a.cfg.PushInterval)
+	defer pushTicker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-syncTicker.C:
+			if err := a.sync(ctx); err != nil {
+				log.Printf("Error fetching assignments: %v", err)
+			}
+		case <-pushTicker.C:
+			if err := a.push(ctx); err != nil {
+				log.Printf("Error pushing results: %v", err)
+			}
+		}
+	}
+}
+
+func (a *Agent) register(ctx context.Context) error {
+	hostname, _ := os.Hostname()
+	var agent domain.Agent
+	if err := a.do(ctx, http.MethodPost, "/agent/register", map[string]string{"hostname": hostname, "version": version()}, &agent); err != nil {
+		return err
+	}
+	log.Printf("Registered as agent %s", agent.Name)
+	return nil
+}
+
+// sync replaces the assignments with the ones the server has now.
+func (a *Agent) sync(ctx context.Context) error {
+	var assignments []usecase.AgentAssignment
+	if err := a.do(ctx, http.MethodGet, "/agent/assignments", nil, &assignments); err != nil {
+		return err
+	}
+	byID := make(map[uint]usecase.AgentAssignment, len(assignments))
+	for _, as := range assignments {
+		byID[as.Device.ID] = as
+	}
+	a.mu.Lock()
+	a.assignments = byID
+	a.mu.Unlock()
+	return nil
+}
+
+// devices lists the assigned devices for the scheduler.
+func (a *Agent) devices() ([]domain.Device, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	devices := make([]domain.Device, 0, len(a.assignments))
+	for _, as := range a.assignments {
+		devices = append(devices, as.Device)
+	}
+	return devices, nil
+}
+
+// check runs the checks of a device and queues the report.
+func (a *Agent) check(ctx context.Context, device domain.Device) {
+	a.mu.Lock()
+	as, ok := a.assignments[device.ID]
+	a.mu.Unlock()
+	if !ok {
+		return
+	}
+	report := usecase.AgentReport{
+		DeviceID:  device.ID,
+		CheckedAt: time.Now(),
+		Results:   usecase.ProbeChecks(ctx, a.probers, device, as.Checks),
+	}
+	a.mu.Lock()
+	a.pending[device.ID] = report
+	a.mu.Unlock()
+}
+
+// push sends the pending reports. They are queued again when the request
+// fails, unless newer ones arrived in the meantime.
+func (a *Agent) push(ctx context.Context) error {
+	a.mu.Lock()
+	reports := make([]usecase.AgentReport, 0, len(a.pending))
+	for _, r := range a.pending {
+		reports = append(reports, r)
+	}
+	a.pending = make(map[uint]usecase.AgentReport)
+	a.mu.Unlock()
+	if len(reports) == 0 {
+		return nil
+	}
+
+	err := a.do(ctx, http.MethodPost, "/agent/results", map[string]interface{}{"reports": reports}, nil)
+	if err != nil {
+		a.mu.Lock()
+		for _, r := range reports {
+			if _, ok := a.pending[r.DeviceID]; !ok {
+				a.pending[r.DeviceID] = r
+			}
+		}
+		a.mu.Unlock()
+	}
+	return err
+}
+
+// do sends an authenticated JSON request to the server and decodes the
+// response into out unless it is nil.
+func (a *Agent) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, a.cfg.Server+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+a.cfg.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&e)
+		return fmt.Errorf("%s %s: %s %s", method, path, resp.Status, e.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func version() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "unknown"
+}
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"log"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"gorm.io/gorm"
+)
+
+// Migrate creates or updates the tables owned by the monitoring subsystems.
+func Migrate(db *gorm.DB) {
+	if err := db.AutoMigrate(
+		&domain.Check{},
+		&domain.Certificate{},
+		&domain.SNMPCredential{},
+		&domain.OIDSet{},
+		&domain.DeviceTypeOIDSet{},
+		&domain.SNMPMetric{},
+		&domain.DeviceMetric{},
+		&domain.AlertRule{},
+		&domain.Alert{},
+		&domain.NotificationChannel{},
+		&domain.NotificationDelivery{},
+		&domain.MaintenanceWindow{},
+		&domain.Silence{},
+		&domain.EscalationPolicy{},
+		&domain.OnCallSchedule{},
+		&domain.OnCallOverride{},
+		&domain.Agent{},
+		&domain.AgentResult{},
+	); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+	// The devices table predates these migrations; only add new columns.
+	if !db.Migrator().HasColumn(&domain.Device{}, "ParentID") {
+		if err := db.Migrator().AddColumn(&domain.Device{}, "ParentID"); err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package delivery
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/usecase"
+)
+
+// agentKey is the context key of the agent authenticated for a request.
+const agentKey = "agent"
+
+type AgentHandler struct {
+	Usecase *usecase.AgentUsecase
+}
+
+func NewAgentHandler(usecase *usecase.AgentUsecase) *AgentHandler {
+	return &AgentHandler{Usecase: usecase}
+}
+
+func (h *AgentHandler) GetAllAgents(c *gin.Context) {
+	agents, err := h.Usecase.GetAllAgents()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, agents)
+}
+
+// CreateAgent returns the new agent with its token, which is not shown
+// again.
+func (h *AgentHandler) CreateAgent(c *gin.Context) {
+	var a domain.Agent
+	if err := c.ShouldBindJSON(&a); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := h.Usecase.CreateAgent(&a); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, a)
+}
+
+func (h *AgentHandler) GetAgentByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+	a, err := h.Usecase.GetAgentByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, a)
+}
+
+func (h *AgentHandler) UpdateAgent(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+	var a domain.Agent
+	if err := c.ShouldBindJSON(&a); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	a.ID = uint(id)
+	if err := h.Usecase.UpdateAgent(&a); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Agent updated successfully"})
+}
+
+func (h *AgentHandler) DeleteAgent(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+	if err := h.Usecase.DeleteAgent(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Agent deleted successfully"})
+}
+
+// RotateToken returns the agent with a new token.
+func (h *AgentHandler) RotateToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+	a, err := h.Usecase.RotateToken(uint(id))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, a)
+}
+
+// GetAgentResults returns the latest results an agent pushed per device.
+func (h *AgentHandler) GetAgentResults(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+	results, err := h.Usecase.GetAgentResults(uint(id))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// Authenticate lets requests through that carry the token of an enabled
+// agent as "Authorization: Bearer <token>".
+func (h *AgentHandler) Authenticate(c *gin.Context) {
+	token, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	a, err := h.Usecase.Authenticate(strings.TrimSpace(token))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	c.Set(agentKey, a)
+	c.Next()
+}
+
+// Register is called by an agent when it starts.
+func (h *AgentHandler) Register(c *gin.Context) {
+	var req struct {
+		Hostname string `json:"hostname"`
+		Version  string `json:"version"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	a := c.MustGet(agentKey).(*domain.Agent)
+	if err := h.Usecase.Register(a, req.Hostname, req.Version); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, a)
+}
+
+// GetAssignments returns the devices and checks the calling agent probes.
+func (h *AgentHandler) GetAssignments(c *gin.Context) {
+	a := c.MustGet(agentKey).(*domain.Agent)
+	assignments, err := h.Usecase.GetAssignments(*a)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, assignments)
+}
+
+// ReportResults accepts a batch of results from the calling agent.
+func (h *AgentHandler) ReportResults(c *gin.Context) {
+	var req struct {
+		Reports []usecase.AgentReport `json:"reports"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	a := c.MustGet(agentKey).(*domain.Agent)
+	n, err := h.Usecase.ReportResults(*a, req.Reports)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"accepted": n})
+}
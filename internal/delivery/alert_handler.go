@@ -0,0 +1,156 @@
+package delivery
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/repository"
+	"github.com/simonaditiabbp/netmon-backend/internal/usecase"
+)
+
+type AlertHandler struct {
+	Usecase *usecase.AlertUsecase
+}
+
+func NewAlertHandler(usecase *usecase.AlertUsecase) *AlertHandler {
+	return &AlertHandler{Usecase: usecase}
+}
+
+func (h *AlertHandler) GetAllRules(c *gin.Context) {
+	rules, err := h.Usecase.GetAllRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+func (h *AlertHandler) CreateRule(c *gin.Context) {
+	var rule domain.AlertRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := h.Usecase.CreateRule(&rule); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+func (h *AlertHandler) GetRuleByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+	rule, err := h.Usecase.GetRuleByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+func (h *AlertHandler) UpdateRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+	var rule domain.AlertRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	rule.ID = uint(id)
+	if err := h.Usecase.UpdateRule(&rule); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Alert rule updated successfully"})
+}
+
+func (h *AlertHandler) DeleteRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+	if err := h.Usecase.DeleteRule(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Alert rule deleted successfully"})
+}
+
+// GetAlerts lists alerts, newest first. ?state= (repeatable or comma
+// separated), ?device_id= and ?rule_id= filter them and ?limit= caps the
+// number returned (default 100).
+func (h *AlertHandler) GetAlerts(c *gin.Context) {
+	filter := repository.AlertFilter{States: queryList(c, "state"), Limit: 100}
+	for key, dst := range map[string]*uint{"device_id": &filter.DeviceID, "rule_id": &filter.RuleID} {
+		if s := c.Query(key); s != "" {
+			id, err := strconv.ParseUint(s, 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + key})
+				return
+			}
+			*dst = uint(id)
+		}
+	}
+	if s := c.Query("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		filter.Limit = limit
+	}
+	alerts, err := h.Usecase.GetAlerts(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, alerts)
+}
+
+func (h *AlertHandler) GetAlertByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return
+	}
+	alert, err := h.Usecase.GetAlertByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, alert)
+}
+
+// AcknowledgeAlert acknowledges a firing alert. The body may name who
+// acknowledged it: {"by": "alice"}.
+func (h *AlertHandler) AcknowledgeAlert(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return
+	}
+	var body struct {
+		By string `json:"by"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+	if err := h.Usecase.AcknowledgeAlert(uint(id), body.By); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Alert acknowledged successfully"})
+}
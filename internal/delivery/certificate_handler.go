@@ -0,0 +1,51 @@
+package delivery
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simonaditiabbp/netmon-backend/internal/usecase"
+)
+
+type CertificateHandler struct {
+	Usecase *usecase.CertificateUsecase
+}
+
+func NewCertificateHandler(usecase *usecase.CertificateUsecase) *CertificateHandler {
+	return &CertificateHandler{Usecase: usecase}
+}
+
+// GetExpiringCertificates lists certificates expiring within ?days=N,
+// defaulting to the configured warning period.
+func (h *CertificateHandler) GetExpiringCertificates(c *gin.Context) {
+	days := 0
+	if s := c.Query("days"); s != "" {
+		d, err := strconv.Atoi(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days"})
+			return
+		}
+		days = d
+	}
+	certs, err := h.Usecase.GetExpiringCertificates(days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, certs)
+}
+
+func (h *CertificateHandler) GetCertificatesByDevice(c *gin.Context) {
+	deviceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	certs, err := h.Usecase.GetCertificatesByDevice(uint(deviceID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, certs)
+}
@@ -0,0 +1,134 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/usecase"
+	"gorm.io/gorm"
+)
+
+type CheckHandler struct {
+	Usecase *usecase.CheckUsecase
+}
+
+func NewCheckHandler(usecase *usecase.CheckUsecase) *CheckHandler {
+	return &CheckHandler{Usecase: usecase}
+}
+
+func (h *CheckHandler) GetChecksByDevice(c *gin.Context) {
+	deviceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	checks, err := h.Usecase.GetChecksByDevice(uint(deviceID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, checks)
+}
+
+func (h *CheckHandler) CreateCheck(c *gin.Context) {
+	deviceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	var check domain.Check
+	if err := c.ShouldBindJSON(&check); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	check.DeviceID = uint(deviceID)
+	if err := h.Usecase.CreateCheck(&check); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, check)
+}
+
+func (h *CheckHandler) UpdateCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+	var check domain.Check
+	if err := c.ShouldBindJSON(&check); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	check.ID = uint(id)
+	if err := h.Usecase.UpdateCheck(&check); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Check updated successfully"})
+}
+
+func (h *CheckHandler) GetCheckByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+	check, err := h.Usecase.GetCheckByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, check)
+}
+
+func (h *CheckHandler) DeleteCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+	if err := h.Usecase.DeleteCheck(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Check deleted successfully"})
+}
+
+// RotateToken replaces the token of a heartbeat check and returns the
+// check with the new one.
+func (h *CheckHandler) RotateToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+	check, err := h.Usecase.RotateToken(uint(id))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, check)
+}
+
+// errorStatus maps usecase validation errors to 400, missing records to
+// 404 and everything else to 500.
+func errorStatus(err error) int {
+	var verr *usecase.ValidationError
+	if errors.As(err, &verr) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, usecase.ErrCheckRunning) {
+		return http.StatusConflict
+	}
+	if errors.Is(err, usecase.ErrNotLeader) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
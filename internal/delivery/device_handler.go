@@ -1,20 +1,37 @@
 package delivery
 
 import (
+	"context"
+	"io"
 	"net/http"
+	"os"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/events"
 	"github.com/simonaditiabbp/netmon-backend/internal/usecase"
 )
 
 type DeviceHandler struct {
-	Usecase *usecase.DeviceUsecase
+	Usecase      *usecase.DeviceUsecase
+	Heartbeat    time.Duration
+	CheckTimeout time.Duration
 }
 
+// NewDeviceHandler sends SSE heartbeats every SSE_HEARTBEAT (default 15s)
+// and gives on-demand checks CHECK_NOW_TIMEOUT (default 30s).
 func NewDeviceHandler(usecase *usecase.DeviceUsecase) *DeviceHandler {
-	return &DeviceHandler{Usecase: usecase}
+	heartbeat := 15 * time.Second
+	if v, err := time.ParseDuration(os.Getenv("SSE_HEARTBEAT")); err == nil && v > 0 {
+		heartbeat = v
+	}
+	checkTimeout := 30 * time.Second
+	if v, err := time.ParseDuration(os.Getenv("CHECK_NOW_TIMEOUT")); err == nil && v > 0 {
+		checkTimeout = v
+	}
+	return &DeviceHandler{Usecase: usecase, Heartbeat: heartbeat, CheckTimeout: checkTimeout}
 }
 
 func (h *DeviceHandler) GetAllDevices(c *gin.Context) {
@@ -26,38 +43,91 @@ func (h *DeviceHandler) GetAllDevices(c *gin.Context) {
 	c.JSON(http.StatusOK, devices)
 }
 
+// SSE streams changes to devices until the client disconnects. Devices can
+// be filtered like on /live. A client that reconnects with a Last-Event-ID
+// still in the hub's replay buffer gets the events it missed; any other
+// client first gets a snapshot of the matching devices as a "message"
+// event. After that only deltas are sent, named after their type
+// (device_added, device_updated, device_deleted, status_changed), each with
+// the totals of the filtered set. A device that stops matching the filter
+// is sent as device_deleted. A comment line is sent every Heartbeat to keep
+// proxies from closing the connection.
 func (h *DeviceHandler) SSE(c *gin.Context) {
-	c.Writer.Header().Set("Content-Type", "text/event-stream")
-	c.Writer.Header().Set("Cache-Control", "no-cache")
-	c.Writer.Header().Set("Connection", "keep-alive")
+	filter, err := parseLiveFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Subscribe before taking the snapshot so no change falls in between.
+	sub := h.Usecase.Hub.SubscribeFrom(lastEventID(c))
+	defer h.Usecase.Hub.Unsubscribe(sub)
 
 	// Query devices from the database
-	devices, err := h.Usecase.GetAllDevices()
+	devices, err := h.Usecase.GetAllDevicesWithTypes()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	view := events.NewView(filter, devices)
+
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
 
-	// Calculate statistics
-	total := len(devices)
-	online := 0
-	for _, device := range devices {
-		if device.Status == "online" {
-			online++
+	if !sub.Resumed {
+		// Send statistics and devices
+		if err := writeSSE(c.Writer, sub.LastID, "message", liveSnapshot(filter.Apply(devices))); err != nil {
+			return
 		}
 	}
-	offline := total - online
-
-	// Send statistics and devices
-	c.SSEvent("message", gin.H{
-		"total":   total,
-		"online":  online,
-		"offline": offline,
-		"devices": devices,
-	})
+	send := func(e events.Event) error {
+		if e, ok := view.Apply(e); ok {
+			return writeSSE(c.Writer, e.ID, e.Type, e)
+		}
+		return nil
+	}
+	for _, e := range sub.Replay {
+		if err := send(e); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		var err error
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				// Dropped for falling behind; the client reconnects.
+				return
+			}
+			err = send(e)
+		case <-heartbeat.C:
+			_, err = io.WriteString(c.Writer, ": ping\n\n")
+		}
+		if err != nil {
+			return
+		}
+		c.Writer.Flush()
+	}
 }
 
+// GetAllLiveDevices returns the devices matching ?location_id=, ?type_ids=
+// and ?status= (each repeatable or comma separated) with their totals.
 func (h *DeviceHandler) GetAllLiveDevices(c *gin.Context) {
+	filter, err := parseLiveFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Query devices from the database
 	devices, err := h.Usecase.GetAllDevicesWithTypes()
 	if err != nil {
@@ -65,24 +135,31 @@ func (h *DeviceHandler) GetAllLiveDevices(c *gin.Context) {
 		return
 	}
 
-	// Calculate statistics
-	total := len(devices)
-	online := 0
-	for _, device := range devices {
-		if device.Status == "online" {
-			online++
-		}
-	}
-	offline := total - online
+	c.JSON(http.StatusOK, liveSnapshot(filter.Apply(devices)))
+}
 
-	data := gin.H{
-		"total":   total,
-		"online":  online,
-		"offline": offline,
+// liveSnapshot is the payload of /live and the first /sse event.
+func liveSnapshot(devices []domain.Device) gin.H {
+	totals := events.CountTotals(devices)
+	return gin.H{
+		"total":   totals.Total,
+		"online":  totals.Online,
+		"offline": totals.Offline,
 		"devices": devices,
 	}
+}
 
-	c.JSON(http.StatusOK, data)
+func parseLiveFilter(c *gin.Context) (events.Filter, error) {
+	var f events.Filter
+	var err error
+	if f.LocationIDs, err = queryIDs(c, "location_id"); err != nil {
+		return f, err
+	}
+	if f.TypeIDs, err = queryIDs(c, "type_ids"); err != nil {
+		return f, err
+	}
+	f.Statuses = queryList(c, "status")
+	return f, nil
 }
 
 func (h *DeviceHandler) InsertDevice(c *gin.Context) {
@@ -93,7 +170,7 @@ func (h *DeviceHandler) InsertDevice(c *gin.Context) {
 	}
 
 	if err := h.Usecase.InsertDeviceWithTypes(&device, device.TypeIDs); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -116,7 +193,7 @@ func (h *DeviceHandler) UpdateDevice(c *gin.Context) {
 	}
 
 	if err := h.Usecase.UpdateDeviceWithTypes(&device, device.TypeIDs); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -166,6 +243,55 @@ func (h *DeviceHandler) DeleteDevice(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Device deleted successfully"})
 }
 
+// CheckDevice runs all checks of a device now and returns the result of
+// each. The observed status applies right away; logs and events are
+// updated like for a scheduled check. A device that is being checked
+// already gets 409, and instances other than the leader answer 503.
+func (h *DeviceHandler) CheckDevice(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.CheckTimeout)
+	defer cancel()
+	report, err := h.Usecase.CheckDeviceNow(ctx, uint(id))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetDeviceTree returns the devices nested under their parents. ?root_id=
+// limits it to one device and the devices behind it.
+func (h *DeviceHandler) GetDeviceTree(c *gin.Context) {
+	var rootID uint64
+	if s := c.Query("root_id"); s != "" {
+		var err error
+		if rootID, err = strconv.ParseUint(s, 10, 32); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid root_id"})
+			return
+		}
+	}
+	tree, err := h.Usecase.GetDeviceTree(uint(rootID))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tree)
+}
+
+// ReceiveHeartbeat accepts a push for the heartbeat check whose token is in
+// the URL. The token is the only credential.
+func (h *DeviceHandler) ReceiveHeartbeat(c *gin.Context) {
+	if err := h.Usecase.ReceiveHeartbeat(c.Param("token")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Heartbeat received"})
+}
+
 func (h *DeviceHandler) GetDevicesByType(c *gin.Context) {
 	typeIDStr := c.Query("type_id")
 	if typeIDStr == "" {
@@ -0,0 +1,107 @@
+package delivery
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simonaditiabbp/netmon-backend/internal/repository"
+	"github.com/simonaditiabbp/netmon-backend/internal/usecase"
+)
+
+type LogHandler struct {
+	Usecase *usecase.LogUsecase
+}
+
+func NewLogHandler(usecase *usecase.LogUsecase) *LogHandler {
+	return &LogHandler{Usecase: usecase}
+}
+
+// GetLogs lists status transitions of all devices. Supported query params:
+// from, to, status (repeatable or comma separated new status), location_id,
+// page, page_size and sort (asc or desc by log time, default desc).
+func (h *LogHandler) GetLogs(c *gin.Context) {
+	filter, ok := logFilter(c)
+	if !ok {
+		return
+	}
+	if s := c.Query("location_id"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location_id"})
+			return
+		}
+		filter.LocationID = uint(id)
+	}
+	h.respond(c, filter)
+}
+
+// GetDeviceLogs lists status transitions of one device and accepts the
+// same query params as GetLogs.
+func (h *LogHandler) GetDeviceLogs(c *gin.Context) {
+	deviceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	filter, ok := logFilter(c)
+	if !ok {
+		return
+	}
+	filter.DeviceID = uint(deviceID)
+	h.respond(c, filter)
+}
+
+func (h *LogHandler) respond(c *gin.Context, filter repository.LogFilter) {
+	logs, total, err := h.Usecase.GetLogs(&filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":      logs,
+		"total":     total,
+		"page":      filter.Page,
+		"page_size": filter.PageSize,
+	})
+}
+
+func logFilter(c *gin.Context) (repository.LogFilter, bool) {
+	var f repository.LogFilter
+	var err error
+	if s := c.Query("from"); s != "" {
+		if f.From, err = parseTime(s); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from"})
+			return f, false
+		}
+	}
+	if s := c.Query("to"); s != "" {
+		if f.To, err = parseTime(s); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to"})
+			return f, false
+		}
+	}
+	f.Statuses = queryList(c, "status")
+	switch strings.ToLower(c.DefaultQuery("sort", "desc")) {
+	case "asc":
+		f.Ascending = true
+	case "desc":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be asc or desc"})
+		return f, false
+	}
+	if s := c.Query("page"); s != "" {
+		if f.Page, err = strconv.Atoi(s); err != nil || f.Page < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page"})
+			return f, false
+		}
+	}
+	if s := c.Query("page_size"); s != "" {
+		if f.PageSize, err = strconv.Atoi(s); err != nil || f.PageSize < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page_size"})
+			return f, false
+		}
+	}
+	return f, true
+}
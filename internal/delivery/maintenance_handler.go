@@ -0,0 +1,175 @@
+package delivery
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/usecase"
+)
+
+type MaintenanceHandler struct {
+	Usecase *usecase.MaintenanceUsecase
+}
+
+func NewMaintenanceHandler(usecase *usecase.MaintenanceUsecase) *MaintenanceHandler {
+	return &MaintenanceHandler{Usecase: usecase}
+}
+
+func (h *MaintenanceHandler) GetAllWindows(c *gin.Context) {
+	windows, err := h.Usecase.GetAllWindows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, windows)
+}
+
+func (h *MaintenanceHandler) CreateWindow(c *gin.Context) {
+	var w domain.MaintenanceWindow
+	if err := c.ShouldBindJSON(&w); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := h.Usecase.CreateWindow(&w); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, w)
+}
+
+func (h *MaintenanceHandler) GetWindowByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid window ID"})
+		return
+	}
+	w, err := h.Usecase.GetWindowByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, w)
+}
+
+func (h *MaintenanceHandler) UpdateWindow(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid window ID"})
+		return
+	}
+	var w domain.MaintenanceWindow
+	if err := c.ShouldBindJSON(&w); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	w.ID = uint(id)
+	if err := h.Usecase.UpdateWindow(&w); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Maintenance window updated successfully"})
+}
+
+func (h *MaintenanceHandler) DeleteWindow(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid window ID"})
+		return
+	}
+	if err := h.Usecase.DeleteWindow(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Maintenance window deleted successfully"})
+}
+
+// GetWindowOccurrences lists when a window is in effect between ?from=
+// and ?to=, by default the next 7 days.
+func (h *MaintenanceHandler) GetWindowOccurrences(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid window ID"})
+		return
+	}
+	from, to := time.Now(), time.Now().Add(7*24*time.Hour)
+	if c.Query("from") != "" || c.Query("to") != "" {
+		if from, to, err = parseTimeRange(c, 7*24*time.Hour); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	periods, err := h.Usecase.GetWindowOccurrences(uint(id), from, to)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, periods)
+}
+
+// GetSilences lists silences, newest first; ?active=true leaves out the
+// expired ones.
+func (h *MaintenanceHandler) GetSilences(c *gin.Context) {
+	silences, err := h.Usecase.GetSilences(c.Query("active") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, silences)
+}
+
+func (h *MaintenanceHandler) CreateSilence(c *gin.Context) {
+	var s domain.Silence
+	if err := c.ShouldBindJSON(&s); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := h.Usecase.CreateSilence(&s); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, s)
+}
+
+func (h *MaintenanceHandler) GetSilenceByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid silence ID"})
+		return
+	}
+	s, err := h.Usecase.GetSilenceByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// ExpireSilence ends a silence now, keeping it for the record.
+func (h *MaintenanceHandler) ExpireSilence(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid silence ID"})
+		return
+	}
+	if err := h.Usecase.ExpireSilence(uint(id)); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Silence expired successfully"})
+}
+
+func (h *MaintenanceHandler) DeleteSilence(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid silence ID"})
+		return
+	}
+	if err := h.Usecase.DeleteSilence(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Silence deleted successfully"})
+}
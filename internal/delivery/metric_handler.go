@@ -0,0 +1,76 @@
+package delivery
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simonaditiabbp/netmon-backend/internal/repository"
+	"github.com/simonaditiabbp/netmon-backend/internal/usecase"
+)
+
+type MetricHandler struct {
+	Usecase *usecase.MetricUsecase
+}
+
+func NewMetricHandler(usecase *usecase.MetricUsecase) *MetricHandler {
+	return &MetricHandler{Usecase: usecase}
+}
+
+// GetDeviceMetrics returns aggregated probe metrics for ?from=&to=
+// (default: the last 24 hours) in buckets of ?step= (e.g. "5m" or 300).
+// ?check_id= and ?type= narrow the result to one check or check type.
+func (h *MetricHandler) GetDeviceMetrics(c *gin.Context) {
+	deviceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	from, to, err := parseTimeRange(c, 24*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var step time.Duration
+	if s := c.Query("step"); s != "" {
+		step, err = parseStep(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid step"})
+			return
+		}
+	}
+	filter := repository.MetricFilter{CheckType: c.Query("type")}
+	if s := c.Query("check_id"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check_id"})
+			return
+		}
+		filter.CheckID = uint(id)
+	}
+
+	points, step, err := h.Usecase.GetDeviceMetrics(uint(deviceID), from, to, step, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from":   from,
+		"to":     to,
+		"step":   step.Seconds(),
+		"points": points,
+	})
+}
+
+// parseStep accepts a Go duration ("5m") or a number of seconds.
+func parseStep(s string) (time.Duration, error) {
+	if n, err := strconv.ParseFloat(s, 64); err == nil && n > 0 {
+		return time.Duration(n * float64(time.Second)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, strconv.ErrSyntax
+	}
+	return d, nil
+}
@@ -0,0 +1,141 @@
+package delivery
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/usecase"
+)
+
+// NotificationHandler serves notification channels. Secrets in channel
+// params are never returned.
+type NotificationHandler struct {
+	Usecase *usecase.NotificationUsecase
+}
+
+func NewNotificationHandler(usecase *usecase.NotificationUsecase) *NotificationHandler {
+	return &NotificationHandler{Usecase: usecase}
+}
+
+func (h *NotificationHandler) GetAllChannels(c *gin.Context) {
+	channels, err := h.Usecase.GetAllChannels()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range channels {
+		channels[i] = channels[i].Redacted()
+	}
+	c.JSON(http.StatusOK, channels)
+}
+
+func (h *NotificationHandler) CreateChannel(c *gin.Context) {
+	var ch domain.NotificationChannel
+	if err := c.ShouldBindJSON(&ch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := h.Usecase.CreateChannel(&ch); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, ch.Redacted())
+}
+
+func (h *NotificationHandler) GetChannelByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+	ch, err := h.Usecase.GetChannelByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ch.Redacted())
+}
+
+// UpdateChannel replaces a channel. Secrets left out of params keep their
+// stored values.
+func (h *NotificationHandler) UpdateChannel(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+	var ch domain.NotificationChannel
+	if err := c.ShouldBindJSON(&ch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	ch.ID = uint(id)
+	if err := h.Usecase.UpdateChannel(&ch); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification channel updated successfully"})
+}
+
+func (h *NotificationHandler) DeleteChannel(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+	if err := h.Usecase.DeleteChannel(uint(id)); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification channel deleted successfully"})
+}
+
+// TestChannel sends a sample alert to a channel once and returns the
+// recorded delivery, failed or not.
+func (h *NotificationHandler) TestChannel(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+	delivery, err := h.Usecase.TestChannel(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, delivery)
+}
+
+// GetDeliveries lists deliveries, newest first, optionally only those of
+// ?alert_id= or ?channel_id=. ?limit= caps the number returned (default
+// 100).
+func (h *NotificationHandler) GetDeliveries(c *gin.Context) {
+	var alertID, channelID uint
+	for key, dst := range map[string]*uint{"alert_id": &alertID, "channel_id": &channelID} {
+		if s := c.Query(key); s != "" {
+			id, err := strconv.ParseUint(s, 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + key})
+				return
+			}
+			*dst = uint(id)
+		}
+	}
+	limit := 100
+	if s := c.Query("limit"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = v
+	}
+	deliveries, err := h.Usecase.GetDeliveries(alertID, channelID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
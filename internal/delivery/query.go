@@ -0,0 +1,68 @@
+package delivery
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseTimeRange reads ?from= and ?to= as RFC 3339 timestamps or Unix
+// seconds. to defaults to now and from to to minus window.
+func parseTimeRange(c *gin.Context, window time.Duration) (time.Time, time.Time, error) {
+	to := time.Now()
+	if s := c.Query("to"); s != "" {
+		t, err := parseTime(s)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("Invalid to")
+		}
+		to = t
+	}
+	from := to.Add(-window)
+	if s := c.Query("from"); s != "" {
+		t, err := parseTime(s)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("Invalid from")
+		}
+		from = t
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+	return from, to, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(n, 0), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// queryList collects a repeatable, comma separated query param.
+func queryList(c *gin.Context, key string) []string {
+	var out []string
+	for _, s := range c.QueryArray(key) {
+		for _, v := range strings.Split(s, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				out = append(out, v)
+			}
+		}
+	}
+	return out
+}
+
+// queryIDs parses a repeatable, comma separated list of IDs.
+func queryIDs(c *gin.Context, key string) ([]uint, error) {
+	var ids []uint
+	for _, s := range queryList(c, key) {
+		id, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return nil, errors.New("Invalid " + key + ": " + s)
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
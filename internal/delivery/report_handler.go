@@ -0,0 +1,40 @@
+package delivery
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simonaditiabbp/netmon-backend/internal/usecase"
+)
+
+type ReportHandler struct {
+	Usecase *usecase.ReportUsecase
+}
+
+func NewReportHandler(usecase *usecase.ReportUsecase) *ReportHandler {
+	return &ReportHandler{Usecase: usecase}
+}
+
+// GetAvailability reports uptime, downtime, outages, MTTR and MTBF for
+// ?from=&to= (default: the last 30 days), grouped by ?group_by=device
+// (default), type or location.
+func (h *ReportHandler) GetAvailability(c *gin.Context) {
+	from, to, err := parseTimeRange(c, 30*24*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	groupBy := c.DefaultQuery("group_by", usecase.GroupByDevice)
+	items, err := h.Usecase.GetAvailability(from, to, groupBy)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from":     from,
+		"to":       to,
+		"group_by": groupBy,
+		"items":    items,
+	})
+}
@@ -0,0 +1,28 @@
+package delivery
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simonaditiabbp/netmon-backend/internal/leader"
+	"github.com/simonaditiabbp/netmon-backend/internal/scheduler"
+)
+
+type SchedulerHandler struct {
+	Scheduler *scheduler.Scheduler
+	Elector   *leader.Elector
+}
+
+func NewSchedulerHandler(s *scheduler.Scheduler, elector *leader.Elector) *SchedulerHandler {
+	return &SchedulerHandler{Scheduler: s, Elector: elector}
+}
+
+func (h *SchedulerHandler) GetStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.Scheduler.LastStats())
+}
+
+// GetLeader tells whether this instance is the one probing devices. The
+// stats of other instances stay empty.
+func (h *SchedulerHandler) GetLeader(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"leader": h.Elector.IsLeader()})
+}
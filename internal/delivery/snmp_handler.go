@@ -0,0 +1,188 @@
+package delivery
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/usecase"
+)
+
+type SNMPHandler struct {
+	Usecase *usecase.SNMPUsecase
+}
+
+func NewSNMPHandler(usecase *usecase.SNMPUsecase) *SNMPHandler {
+	return &SNMPHandler{Usecase: usecase}
+}
+
+func (h *SNMPHandler) GetCredential(c *gin.Context) {
+	deviceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	cred, err := h.Usecase.GetCredential(uint(deviceID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, cred.Redacted())
+}
+
+func (h *SNMPHandler) SaveCredential(c *gin.Context) {
+	deviceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	var cred domain.SNMPCredential
+	if err := c.ShouldBindJSON(&cred); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	cred.DeviceID = uint(deviceID)
+	if err := h.Usecase.SaveCredential(&cred); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "SNMP credential saved successfully"})
+}
+
+func (h *SNMPHandler) DeleteCredential(c *gin.Context) {
+	deviceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	if err := h.Usecase.DeleteCredential(uint(deviceID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "SNMP credential deleted successfully"})
+}
+
+// GetMetrics returns polled values for ?from=&to= (default: the last hour),
+// optionally only for ?name=.
+func (h *SNMPHandler) GetMetrics(c *gin.Context) {
+	deviceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	from, to, err := parseTimeRange(c, time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	metrics, err := h.Usecase.GetMetrics(uint(deviceID), c.Query("name"), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, metrics)
+}
+
+func (h *SNMPHandler) CreateOIDSet(c *gin.Context) {
+	var set domain.OIDSet
+	if err := c.ShouldBindJSON(&set); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := h.Usecase.CreateOIDSet(&set); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, set)
+}
+
+func (h *SNMPHandler) UpdateOIDSet(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid OID set ID"})
+		return
+	}
+	var set domain.OIDSet
+	if err := c.ShouldBindJSON(&set); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	set.ID = uint(id)
+	if err := h.Usecase.UpdateOIDSet(&set); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "OID set updated successfully"})
+}
+
+func (h *SNMPHandler) GetAllOIDSets(c *gin.Context) {
+	sets, err := h.Usecase.GetAllOIDSets()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sets)
+}
+
+func (h *SNMPHandler) GetOIDSetByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid OID set ID"})
+		return
+	}
+	set, err := h.Usecase.GetOIDSetByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, set)
+}
+
+func (h *SNMPHandler) DeleteOIDSet(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid OID set ID"})
+		return
+	}
+	if err := h.Usecase.DeleteOIDSet(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "OID set deleted successfully"})
+}
+
+func (h *SNMPHandler) GetTypeOIDSets(c *gin.Context) {
+	typeID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device type ID"})
+		return
+	}
+	sets, err := h.Usecase.GetOIDSetsByType(uint(typeID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sets)
+}
+
+func (h *SNMPHandler) SetTypeOIDSets(c *gin.Context) {
+	typeID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device type ID"})
+		return
+	}
+	var body struct {
+		OIDSetIDs []uint `json:"oid_set_ids"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := h.Usecase.SetTypeOIDSets(uint(typeID), body.OIDSetIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "OID sets updated successfully"})
+}
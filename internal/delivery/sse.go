@@ -0,0 +1,33 @@
+package delivery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeSSE writes one server-sent event with an id, so EventSource clients
+// send it back as Last-Event-ID when they reconnect.
+func writeSSE(w io.Writer, id uint64, event string, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, b)
+	return err
+}
+
+// lastEventID reads the Last-Event-ID header, or the last_event_id query
+// param for clients that cannot set headers. It returns 0 when absent or
+// invalid.
+func lastEventID(c *gin.Context) uint64 {
+	s := c.GetHeader("Last-Event-ID")
+	if s == "" {
+		s = c.Query("last_event_id")
+	}
+	id, _ := strconv.ParseUint(s, 10, 64)
+	return id
+}
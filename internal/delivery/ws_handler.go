@@ -0,0 +1,290 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/simonaditiabbp/netmon-backend/internal/events"
+	"github.com/simonaditiabbp/netmon-backend/internal/usecase"
+)
+
+// WebSocket protocol
+//
+// Every message is a JSON object with a "type". Client messages may carry
+// an "id" that is echoed in the reply to that message.
+//
+// Client to server:
+//
+//	{"type":"subscribe","id":"1","location_ids":[1],"type_ids":[2],"statuses":["offline"],"last_event_id":0}
+//	    Starts (or replaces) the event subscription. All filter fields are
+//	    optional. Replied to with "snapshot", or with "ok" when
+//	    last_event_id could be resumed and only missed events follow.
+//	{"type":"unsubscribe","id":"2"}
+//	    Stops the event subscription. Replied to with "ok".
+//	{"type":"check","id":"3","device_id":7}
+//	    Runs all checks of a device now. Replied to with "ok" right away and
+//	    with "checked", carrying the same result as POST /devices/:id/check,
+//	    once the checks are done.
+//	{"type":"ack_alert","id":"4","alert_id":9,"by":"alice"}
+//	    Acknowledges an alert. Replied to with "ok".
+//
+// Server to client:
+//
+//	{"type":"snapshot","id":"1","last_event_id":N,"total":..,"online":..,"offline":..,"devices":[..]}
+//	{"type":"event","event":{..}}      same payload as the /sse events
+//	{"type":"checked","id":"3","result":{"device_id":7,"status":"online","checks":[..],..}}
+//	{"type":"ok","id":"2"}
+//	{"type":"error","id":"4","error":"..."}
+//
+// A subscription that falls too far behind is dropped with an error;
+// subscribe again with the last event ID seen to resume.
+
+const (
+	wsWriteWait    = 10 * time.Second
+	wsPongWait     = 60 * time.Second
+	wsPingPeriod   = wsPongWait * 9 / 10
+	wsMaxMessage   = 64 << 10
+	wsCheckTimeout = 30 * time.Second
+)
+
+// AlertAcknowledger acknowledges alerts for WebSocket clients. It may be
+// nil, in which case ack_alert is answered with an error.
+type AlertAcknowledger interface {
+	AcknowledgeAlert(id uint, by string) error
+}
+
+type WSHandler struct {
+	Usecase  *usecase.DeviceUsecase
+	Alerts   AlertAcknowledger
+	Upgrader websocket.Upgrader
+}
+
+// NewWSHandler accepts connections from the origins in ALLOWED_ORIGINS,
+// like the CORS middleware, and from clients that send no Origin.
+func NewWSHandler(usecase *usecase.DeviceUsecase, alerts AlertAcknowledger) *WSHandler {
+	allowedOrigins := os.Getenv("ALLOWED_ORIGINS")
+	return &WSHandler{
+		Usecase: usecase,
+		Alerts:  alerts,
+		Upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				return origin == "" || strings.Contains(allowedOrigins, origin)
+			},
+		},
+	}
+}
+
+type wsMessage struct {
+	Type        string   `json:"type"`
+	ID          string   `json:"id,omitempty"`
+	DeviceID    uint     `json:"device_id,omitempty"`
+	AlertID     uint     `json:"alert_id,omitempty"`
+	By          string   `json:"by,omitempty"`
+	LastEventID uint64   `json:"last_event_id,omitempty"`
+	LocationIDs []uint   `json:"location_ids,omitempty"`
+	TypeIDs     []uint   `json:"type_ids,omitempty"`
+	Statuses    []string `json:"statuses,omitempty"`
+}
+
+func (h *WSHandler) Serve(c *gin.Context) {
+	conn, err := h.Upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade has already replied with an error.
+		return
+	}
+	s := &wsSession{
+		h:    h,
+		conn: conn,
+		out:  make(chan interface{}, 64),
+		done: make(chan struct{}),
+		dead: make(chan struct{}),
+	}
+	go s.writeLoop()
+	s.readLoop()
+	s.close()
+}
+
+type wsSession struct {
+	h    *WSHandler
+	conn *websocket.Conn
+	out  chan interface{}
+	done chan struct{} // closed when the session ends
+	dead chan struct{} // closed when the writer stops
+
+	mu  sync.Mutex
+	sub *events.Subscription
+}
+
+func (s *wsSession) readLoop() {
+	s.conn.SetReadLimit(wsMaxMessage)
+	s.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		// Read errors are permanent, so any of them ends the session.
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var m wsMessage
+		if err := json.Unmarshal(data, &m); err != nil {
+			s.reply("", errors.New("invalid message: "+err.Error()))
+			continue
+		}
+		s.handle(m)
+	}
+}
+
+func (s *wsSession) handle(m wsMessage) {
+	switch m.Type {
+	case "subscribe":
+		s.subscribe(m)
+	case "unsubscribe":
+		s.unsubscribe()
+		s.reply(m.ID, nil)
+	case "check":
+		s.check(m)
+	case "ack_alert":
+		if s.h.Alerts == nil {
+			s.reply(m.ID, errors.New("alerts are not available"))
+			return
+		}
+		s.reply(m.ID, s.h.Alerts.AcknowledgeAlert(m.AlertID, m.By))
+	default:
+		s.reply(m.ID, errors.New("unknown message type "+m.Type))
+	}
+}
+
+func (s *wsSession) subscribe(m wsMessage) {
+	s.unsubscribe()
+	hub := s.h.Usecase.Hub
+	sub := hub.SubscribeFrom(m.LastEventID)
+	devices, err := s.h.Usecase.GetAllDevicesWithTypes()
+	if err != nil {
+		hub.Unsubscribe(sub)
+		s.reply(m.ID, err)
+		return
+	}
+	filter := events.Filter{LocationIDs: m.LocationIDs, TypeIDs: m.TypeIDs, Statuses: m.Statuses}
+	view := events.NewView(filter, devices)
+	s.mu.Lock()
+	s.sub = sub
+	s.mu.Unlock()
+
+	if sub.Resumed {
+		s.reply(m.ID, nil)
+	} else {
+		snapshot := liveSnapshot(filter.Apply(devices))
+		snapshot["type"] = "snapshot"
+		snapshot["id"] = m.ID
+		snapshot["last_event_id"] = sub.LastID
+		s.send(snapshot)
+	}
+
+	go func() {
+		forward := func(e events.Event) {
+			if e, ok := view.Apply(e); ok {
+				s.send(gin.H{"type": "event", "event": e})
+			}
+		}
+		for _, e := range sub.Replay {
+			forward(e)
+		}
+		for e := range sub.C {
+			forward(e)
+		}
+		s.mu.Lock()
+		dropped := s.sub == sub
+		if dropped {
+			s.sub = nil
+		}
+		s.mu.Unlock()
+		if dropped {
+			s.send(gin.H{"type": "error", "error": "subscription dropped for falling behind; subscribe again with last_event_id"})
+		}
+	}()
+}
+
+func (s *wsSession) unsubscribe() {
+	s.mu.Lock()
+	sub := s.sub
+	s.sub = nil
+	s.mu.Unlock()
+	if sub != nil {
+		s.h.Usecase.Hub.Unsubscribe(sub)
+	}
+}
+
+func (s *wsSession) check(m wsMessage) {
+	s.reply(m.ID, nil)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), wsCheckTimeout)
+		defer cancel()
+		report, err := s.h.Usecase.CheckDeviceNow(ctx, m.DeviceID)
+		if err != nil {
+			s.reply(m.ID, err)
+			return
+		}
+		s.send(gin.H{"type": "checked", "id": m.ID, "result": report})
+	}()
+}
+
+// reply answers a client message with "ok" or, when err is set, "error".
+func (s *wsSession) reply(id string, err error) {
+	if err != nil {
+		s.send(gin.H{"type": "error", "id": id, "error": err.Error()})
+		return
+	}
+	s.send(gin.H{"type": "ok", "id": id})
+}
+
+// send queues a message for the client. It blocks while the queue is
+// full, which makes a slow client's subscription fall behind and get
+// dropped by the hub rather than stalling it.
+func (s *wsSession) send(msg interface{}) {
+	select {
+	case s.out <- msg:
+	case <-s.done:
+	case <-s.dead:
+	}
+}
+
+func (s *wsSession) writeLoop() {
+	defer close(s.dead)
+	ping := time.NewTicker(wsPingPeriod)
+	defer ping.Stop()
+	for {
+		select {
+		case <-s.done:
+			s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(wsWriteWait))
+			return
+		case msg := <-s.out:
+			s.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := s.conn.WriteJSON(msg); err != nil {
+				s.conn.Close()
+				return
+			}
+		case <-ping.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				s.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+func (s *wsSession) close() {
+	close(s.done)
+	s.unsubscribe()
+	s.conn.Close()
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// Agent is a copy of this binary running in agent mode somewhere the
+// server cannot reach, such as a branch office LAN. It probes the devices
+// listed in DeviceIDs and every device at LocationIDs and pushes the
+// results to the server.
+type Agent struct {
+	ID          uint     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name        string   `gorm:"not null" json:"name"`
+	Description string   `json:"description"`
+	DeviceIDs   UintList `json:"device_ids"`
+	LocationIDs UintList `json:"location_ids"`
+	Disabled    bool     `gorm:"not null;default:false" json:"disabled"`
+	// TokenHash is the SHA-256 of the agent's API token. The token itself
+	// is only returned when it is created or rotated.
+	TokenHash    string     `gorm:"uniqueIndex" json:"-"`
+	Token        string     `gorm:"-" json:"token,omitempty"`
+	Hostname     string     `json:"hostname"`
+	Version      string     `json:"version"`
+	RegisteredAt *time.Time `json:"registered_at"`
+	LastSeenAt   *time.Time `json:"last_seen_at"`
+	Online       bool       `gorm:"-" json:"online"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	CreatedBy    string     `json:"created_by"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	UpdatedBy    string     `json:"updated_by"`
+}
+
+// Covers reports whether the agent probes device.
+func (a Agent) Covers(device Device) bool {
+	if a.Disabled {
+		return false
+	}
+	return slices.Contains(a.DeviceIDs, device.ID) || slices.Contains(a.LocationIDs, device.LocationID)
+}
+
+// AgentResult holds the latest results an agent reported for a device.
+type AgentResult struct {
+	AgentID  uint   `gorm:"primaryKey;autoIncrement:false" json:"agent_id"`
+	DeviceID uint   `gorm:"primaryKey;autoIncrement:false;index" json:"device_id"`
+	Status   string `json:"status"`
+	// Results holds the probe results as the agent sent them.
+	Results    json.RawMessage `gorm:"type:jsonb" json:"results"`
+	CheckedAt  time.Time       `json:"checked_at"`  // by the agent's clock
+	ReportedAt time.Time       `json:"reported_at"` // by the server's clock
+}
@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	// AlertConditionOffline fires when a device has been offline for at
+	// least ForMinutes. Unreachable devices, behind a parent that is down,
+	// do not fire; devices unreachable because none of their agents
+	// reports do.
+	AlertConditionOffline = "offline"
+	// AlertConditionLatency fires when the latency of a device's checks
+	// stays above Threshold ms for ForMinutes.
+	AlertConditionLatency = "latency"
+	// AlertConditionCertExpiry fires when a certificate of a device
+	// expires within Threshold days.
+	AlertConditionCertExpiry = "cert_expiry"
+	// AlertConditionLocationDown fires when more than Threshold percent of
+	// the devices in a location are offline or unreachable.
+	AlertConditionLocationDown = "location_down"
+)
+
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+const (
+	AlertFiring       = "firing"
+	AlertAcknowledged = "acknowledged"
+	AlertResolved     = "resolved"
+)
+
+// AlertRule describes when alerts are raised. DeviceIDs, TypeIDs and
+// LocationIDs limit the devices the rule applies to; empty lists match all.
+type AlertRule struct {
+	ID          uint     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name        string   `gorm:"not null" json:"name"`
+	Condition   string   `gorm:"not null" json:"condition"`
+	Threshold   float64  `json:"threshold"`
+	ForMinutes  int      `json:"for_minutes"`
+	CheckType   string   `json:"check_type"` // latency rules only; empty means any check
+	Severity    string   `gorm:"not null;default:warning" json:"severity"`
+	DeviceIDs   UintList `json:"device_ids"`
+	TypeIDs     UintList `json:"type_ids"`
+	LocationIDs UintList `json:"location_ids"`
+	// EscalationPolicyID is walked by alerts of this rule until they are
+	// acknowledged; 0 for none.
+	EscalationPolicyID uint      `json:"escalation_policy_id"`
+	Disabled           bool      `gorm:"not null;default:false" json:"disabled"`
+	CreatedAt          time.Time `gorm:"autoCreateTime" json:"created_at"`
+	CreatedBy          string    `json:"created_by"`
+	UpdatedAt          time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	UpdatedBy          string    `json:"updated_by"`
+}
+
+// Matches reports whether a device with the given types is in the rule's
+// scope.
+func (r AlertRule) Matches(device Device, typeIDs []uint) bool {
+	if len(r.DeviceIDs) > 0 && !slices.Contains(r.DeviceIDs, device.ID) {
+		return false
+	}
+	if len(r.LocationIDs) > 0 && !slices.Contains(r.LocationIDs, device.LocationID) {
+		return false
+	}
+	if len(r.TypeIDs) > 0 {
+		for _, id := range typeIDs {
+			if slices.Contains(r.TypeIDs, id) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Alert is one occurrence of a rule firing for a device or, for location
+// rules, a location. Key identifies the rule and subject so a condition
+// that keeps holding updates the open alert instead of raising new ones.
+type Alert struct {
+	ID             uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	RuleID         uint       `gorm:"not null;index" json:"rule_id"`
+	Key            string     `gorm:"not null;index" json:"key"`
+	DeviceID       uint       `gorm:"index" json:"device_id,omitempty"`
+	LocationID     uint       `json:"location_id,omitempty"`
+	Severity       string     `gorm:"not null" json:"severity"`
+	State          string     `gorm:"not null;index" json:"state"`
+	Message        string     `json:"message"`
+	Value          float64    `json:"value"`
+	StartedAt      time.Time  `gorm:"not null" json:"started_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+	AcknowledgedBy string     `json:"acknowledged_by"`
+	ResolvedAt     *time.Time `json:"resolved_at"`
+	// EscalationStep counts the escalation steps taken so far, the last
+	// at EscalatedAt.
+	EscalationStep int        `gorm:"not null;default:0" json:"escalation_step"`
+	EscalatedAt    *time.Time `json:"escalated_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	RuleName       string     `gorm:"->;-:migration" json:"rule_name,omitempty"`
+	DeviceName     string     `gorm:"->;-:migration" json:"device_name,omitempty"`
+}
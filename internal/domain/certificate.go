@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// Certificate is the last TLS certificate seen for a device endpoint.
+// NotAfter is the earliest expiry in the presented chain, so an expiring
+// intermediate is reported as well as an expiring leaf.
+type Certificate struct {
+	ID              uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	DeviceID        uint       `gorm:"not null;uniqueIndex:idx_certificates_endpoint" json:"device_id"`
+	DeviceName      string     `gorm:"->;-:migration" json:"device_name,omitempty"`
+	CheckID         uint       `gorm:"index" json:"check_id"`
+	Host            string     `gorm:"not null;uniqueIndex:idx_certificates_endpoint" json:"host"`
+	Port            int        `gorm:"not null;uniqueIndex:idx_certificates_endpoint" json:"port"`
+	Subject         string     `json:"subject"`
+	Issuer          string     `json:"issuer"`
+	SANs            StringList `gorm:"column:sans" json:"sans"`
+	SerialNumber    string     `json:"serial_number"`
+	NotBefore       time.Time  `json:"not_before"`
+	NotAfter        time.Time  `gorm:"index" json:"not_after"`
+	ValidationError string     `json:"validation_error"`
+	CheckedAt       time.Time  `json:"checked_at"`
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	CheckTypeICMP = "icmp"
+	CheckTypeHTTP = "http"
+	CheckTypeTCP  = "tcp"
+	CheckTypeTLS  = "tls"
+	CheckTypeDNS  = "dns"
+	// CheckTypeHeartbeat is passive: the device pushes heartbeats to
+	// /heartbeat/:token instead of being probed.
+	CheckTypeHeartbeat = "heartbeat"
+)
+
+// Check is a single probe configured for a device. Params holds the
+// type-specific settings as JSON, e.g. {"count": 3} for an ICMP check.
+type Check struct {
+	ID            uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	DeviceID      uint            `gorm:"not null;index" json:"device_id"`
+	Type          string          `gorm:"not null" json:"type"`
+	Name          string          `json:"name"`
+	Params        json.RawMessage `gorm:"type:jsonb" json:"params"`
+	Disabled      bool            `gorm:"not null;default:false" json:"disabled"`
+	LastStatus    string          `json:"last_status"`
+	LastError     string          `json:"last_error"`
+	LastLatencyMs float64         `json:"last_latency_ms"`
+	LastDetails   json.RawMessage `gorm:"type:jsonb" json:"last_details"`
+	LastCheckedAt *time.Time      `json:"last_checked_at"`
+	// Token is the secret of a heartbeat check's push URL.
+	Token           *string    `gorm:"uniqueIndex" json:"token,omitempty"`
+	LastHeartbeatAt *time.Time `json:"last_heartbeat_at,omitempty"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	CreatedBy       string     `json:"created_by"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	UpdatedBy       string     `json:"updated_by"`
+}
@@ -2,6 +2,17 @@ package domain
 
 import "time"
 
+const (
+	StatusOnline   = "online"
+	StatusOffline  = "offline"
+	StatusDegraded = "degraded"
+	StatusWarning  = "warning"
+	StatusFlapping = "flapping"
+	// StatusUnreachable is reported instead of offline for a device whose
+	// parent is down, so only the root cause counts as offline.
+	StatusUnreachable = "unreachable"
+)
+
 type Device struct {
 	ID         uint   `gorm:"primaryKey;autoIncrement"`
 	Name       string `gorm:"not null"`
@@ -18,4 +29,15 @@ type Device struct {
 	Types      []DeviceType `json:"types" gorm:"-"`    // for response
 	LocationID uint         `json:"location_id"`
 	Location   *Location    `json:"location" gorm:"-"`
+	ParentID   *uint        `json:"parent_id"` // uplink; 0 or null for none
+}
+
+// DeviceNode is a device in the dependency tree, with the devices that
+// depend on it.
+type DeviceNode struct {
+	ID         uint         `json:"id"`
+	Name       string       `json:"name"`
+	Status     string       `json:"status"`
+	LocationID uint         `json:"location_id"`
+	Children   []DeviceNode `json:"children"`
 }
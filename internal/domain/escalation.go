@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+const (
+	EscalationTargetChannel  = "channel"  // a notification channel
+	EscalationTargetSchedule = "schedule" // whoever is on call
+)
+
+type EscalationTarget struct {
+	Type string `json:"type"`
+	ID   uint   `json:"id"`
+}
+
+// EscalationStep notifies its targets DelayMinutes after the previous step
+// or, for the first step, after the alert started firing.
+type EscalationStep struct {
+	DelayMinutes int                `json:"delay_minutes"`
+	Targets      []EscalationTarget `json:"targets"`
+}
+
+// EscalationSteps is stored as a JSON array in a jsonb column.
+type EscalationSteps []EscalationStep
+
+func (s EscalationSteps) Value() (driver.Value, error) {
+	if s == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]EscalationStep(s))
+	return string(b), err
+}
+
+func (s *EscalationSteps) Scan(src interface{}) error {
+	return scanJSON(src, (*[]EscalationStep)(s))
+}
+
+func (EscalationSteps) GormDataType() string {
+	return "jsonb"
+}
+
+// EscalationPolicy is walked step by step by a firing alert until it is
+// acknowledged. After the last step the policy starts over Repeat more
+// times.
+type EscalationPolicy struct {
+	ID          uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name        string          `gorm:"not null" json:"name"`
+	Description string          `json:"description"`
+	Steps       EscalationSteps `json:"steps"`
+	Repeat      int             `json:"repeat"`
+	CreatedAt   time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	CreatedBy   string          `json:"created_by"`
+	UpdatedAt   time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+	UpdatedBy   string          `json:"updated_by"`
+}
+
+// OnCallParticipant is a person in a rotation, reached through a
+// notification channel.
+type OnCallParticipant struct {
+	Name      string `json:"name"`
+	ChannelID uint   `json:"channel_id"`
+}
+
+// OnCallParticipants is stored as a JSON array in a jsonb column.
+type OnCallParticipants []OnCallParticipant
+
+func (p OnCallParticipants) Value() (driver.Value, error) {
+	if p == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]OnCallParticipant(p))
+	return string(b), err
+}
+
+func (p *OnCallParticipants) Scan(src interface{}) error {
+	return scanJSON(src, (*[]OnCallParticipant)(p))
+}
+
+func (OnCallParticipants) GormDataType() string {
+	return "jsonb"
+}
+
+// OnCallSchedule hands on-call duty to its participants in turn, each for
+// RotationHours, starting with the first at RotationStart.
+type OnCallSchedule struct {
+	ID            uint               `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name          string             `gorm:"not null" json:"name"`
+	RotationStart time.Time          `gorm:"not null" json:"rotation_start"`
+	RotationHours int                `gorm:"not null" json:"rotation_hours"`
+	Participants  OnCallParticipants `json:"participants"`
+	CreatedAt     time.Time          `gorm:"autoCreateTime" json:"created_at"`
+	CreatedBy     string             `json:"created_by"`
+	UpdatedAt     time.Time          `gorm:"autoUpdateTime" json:"updated_at"`
+	UpdatedBy     string             `json:"updated_by"`
+}
+
+// OnCallOverride puts someone else on call from StartsAt until EndsAt.
+type OnCallOverride struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ScheduleID uint      `gorm:"not null;index" json:"schedule_id"`
+	Name       string    `gorm:"not null" json:"name"`
+	ChannelID  uint      `gorm:"not null" json:"channel_id"`
+	StartsAt   time.Time `gorm:"not null" json:"starts_at"`
+	EndsAt     time.Time `gorm:"not null" json:"ends_at"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	CreatedBy  string    `json:"created_by"`
+}
+
+// OnCallAt returns who is on call at t. The latest override covering t
+// wins over the rotation.
+func (s OnCallSchedule) OnCallAt(t time.Time, overrides []OnCallOverride) (OnCallParticipant, bool) {
+	var override *OnCallOverride
+	for i, o := range overrides {
+		if o.ScheduleID == s.ID && !t.Before(o.StartsAt) && t.Before(o.EndsAt) &&
+			(override == nil || o.CreatedAt.After(override.CreatedAt) || (o.CreatedAt.Equal(override.CreatedAt) && o.ID > override.ID)) {
+			override = &overrides[i]
+		}
+	}
+	if override != nil {
+		return OnCallParticipant{Name: override.Name, ChannelID: override.ChannelID}, true
+	}
+	if len(s.Participants) == 0 || s.RotationHours <= 0 {
+		return OnCallParticipant{}, false
+	}
+	shift := time.Duration(s.RotationHours) * time.Hour
+	n := int64(t.Sub(s.RotationStart) / shift)
+	if t.Before(s.RotationStart) && t.Sub(s.RotationStart)%shift != 0 {
+		n-- // round towards the earlier shift
+	}
+	i := n % int64(len(s.Participants))
+	if i < 0 {
+		i += int64(len(s.Participants))
+	}
+	return s.Participants[i], true
+}
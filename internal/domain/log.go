@@ -3,11 +3,14 @@ package domain
 import "time"
 
 type Log struct {
-	ID        uint      `gorm:"primaryKey"`
-	DeviceID  uint      `gorm:"not null"`
-	OldStatus string    `gorm:"column:oldstatus;not null"`
-	NewStatus string    `gorm:"column:newstatus;not null"`
-	Logtime   time.Time `gorm:"column:log_time;autoCreateTime"`
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	DeviceID     uint      `gorm:"not null" json:"device_id"`
+	OldStatus    string    `gorm:"column:oldstatus;not null" json:"old_status"`
+	NewStatus    string    `gorm:"column:newstatus;not null" json:"new_status"`
+	Logtime      time.Time `gorm:"column:log_time;autoCreateTime" json:"log_time"`
+	DeviceName   string    `gorm:"->;-:migration" json:"device_name,omitempty"`
+	LocationID   uint      `gorm:"->;-:migration" json:"location_id,omitempty"`
+	LocationName string    `gorm:"->;-:migration" json:"location_name,omitempty"`
 }
 
 // TableName overrides the default table name
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// MaintenanceWindow is planned work on the devices it covers: those in
+// DeviceIDs, with a type in TypeIDs or at a location in LocationIDs.
+// Alerts are not raised for covered devices during the window and the
+// window is left out of availability reports.
+//
+// A one-off window runs from StartsAt to EndsAt. A recurring window starts
+// whenever Schedule, a cron expression ("0 2 * * 0", optionally prefixed
+// with CRON_TZ=<zone>), fires and lasts DurationMinutes; StartsAt and
+// EndsAt, when set, bound the time it recurs in.
+type MaintenanceWindow struct {
+	ID              uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name            string     `gorm:"not null" json:"name"`
+	Description     string     `json:"description"`
+	DeviceIDs       UintList   `json:"device_ids"`
+	TypeIDs         UintList   `json:"type_ids"`
+	LocationIDs     UintList   `json:"location_ids"`
+	StartsAt        *time.Time `json:"starts_at"`
+	EndsAt          *time.Time `json:"ends_at"`
+	Schedule        string     `json:"schedule"`
+	DurationMinutes int        `json:"duration_minutes"`
+	Disabled        bool       `gorm:"not null;default:false" json:"disabled"`
+	Active          bool       `gorm:"-" json:"active"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	CreatedBy       string     `json:"created_by"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	UpdatedBy       string     `json:"updated_by"`
+}
+
+// Covers reports whether a device with the given types is in the window's
+// scope.
+func (w MaintenanceWindow) Covers(device Device, typeIDs []uint) bool {
+	if slices.Contains(w.DeviceIDs, device.ID) || slices.Contains(w.LocationIDs, device.LocationID) {
+		return true
+	}
+	for _, id := range typeIDs {
+		if slices.Contains(w.TypeIDs, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// Period is a span of time [Start, End).
+type Period struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Silence mutes alerts from StartsAt until ExpiresAt. An alert is muted
+// when it matches every non-empty list of the silence; at least one list
+// must be set.
+type Silence struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Reason      string    `gorm:"not null" json:"reason"`
+	RuleIDs     UintList  `json:"rule_ids"`
+	DeviceIDs   UintList  `json:"device_ids"`
+	LocationIDs UintList  `json:"location_ids"`
+	StartsAt    time.Time `gorm:"not null" json:"starts_at"`
+	ExpiresAt   time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	CreatedBy   string    `json:"created_by"`
+}
+
+func (s Silence) ActiveAt(t time.Time) bool {
+	return !t.Before(s.StartsAt) && t.Before(s.ExpiresAt)
+}
+
+// Mutes reports whether the silence matches alert. A silence without any
+// matcher mutes nothing.
+func (s Silence) Mutes(alert Alert) bool {
+	if len(s.RuleIDs) == 0 && len(s.DeviceIDs) == 0 && len(s.LocationIDs) == 0 {
+		return false
+	}
+	return (len(s.RuleIDs) == 0 || slices.Contains(s.RuleIDs, alert.RuleID)) &&
+		(len(s.DeviceIDs) == 0 || slices.Contains(s.DeviceIDs, alert.DeviceID)) &&
+		(len(s.LocationIDs) == 0 || slices.Contains(s.LocationIDs, alert.LocationID))
+}
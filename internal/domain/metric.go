@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// DeviceMetric is the outcome of one probe. RTTMs is empty when the probe
+// failed and LossPct is only set by checks that measure packet loss.
+type DeviceMetric struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	DeviceID   uint      `gorm:"not null;index:idx_device_metrics_series,priority:1" json:"device_id"`
+	CheckID    uint      `gorm:"index" json:"check_id"`
+	CheckType  string    `gorm:"not null" json:"check_type"`
+	Status     string    `gorm:"not null" json:"status"`
+	RTTMs      *float64  `gorm:"column:rtt_ms" json:"rtt_ms"`
+	LossPct    *float64  `json:"loss_pct"`
+	DurationMs float64   `json:"duration_ms"`
+	MeasuredAt time.Time `gorm:"not null;index:idx_device_metrics_series,priority:2" json:"measured_at"`
+}
+
+// MetricPoint aggregates the metrics of one time bucket.
+type MetricPoint struct {
+	Time          time.Time `json:"time"`
+	RTTAvgMs      *float64  `gorm:"column:rtt_avg_ms" json:"rtt_avg_ms"`
+	RTTMinMs      *float64  `gorm:"column:rtt_min_ms" json:"rtt_min_ms"`
+	RTTMaxMs      *float64  `gorm:"column:rtt_max_ms" json:"rtt_max_ms"`
+	LossAvgPct    *float64  `json:"loss_avg_pct"`
+	DurationAvgMs float64   `json:"duration_avg_ms"`
+	Samples       int       `json:"samples"`
+	Failures      int       `json:"failures"`
+}
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// NotificationChannel is a destination for alert notifications. Params
+// holds the settings of its type, e.g. {"url": "...", "secret": "..."}
+// for a webhook. MinSeverity and Events limit which alert changes are
+// sent; empty values send everything.
+type NotificationChannel struct {
+	ID              uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name            string          `gorm:"not null" json:"name"`
+	Type            string          `gorm:"not null" json:"type"`
+	Params          json.RawMessage `gorm:"type:jsonb" json:"params"`
+	SubjectTemplate string          `json:"subject_template"`
+	BodyTemplate    string          `json:"body_template"`
+	MinSeverity     string          `json:"min_severity"`
+	Events          StringList      `json:"events"` // firing, acknowledged, resolved
+	Disabled        bool            `gorm:"not null;default:false" json:"disabled"`
+	CreatedAt       time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	CreatedBy       string          `json:"created_by"`
+	UpdatedAt       time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+	UpdatedBy       string          `json:"updated_by"`
+}
+
+// secretParams are removed from channel params in API responses.
+var secretParams = []string{"secret", "password", "token"}
+
+// Redacted returns a copy without secrets, for API responses.
+func (c NotificationChannel) Redacted() NotificationChannel {
+	var params map[string]json.RawMessage
+	if err := json.Unmarshal(c.Params, &params); err != nil {
+		return c
+	}
+	for _, k := range secretParams {
+		delete(params, k)
+	}
+	c.Params, _ = json.Marshal(params)
+	return c
+}
+
+// KeepSecrets copies the secrets of old into c where c's params leave
+// them out, so a redacted channel can be sent back unchanged.
+func (c *NotificationChannel) KeepSecrets(old NotificationChannel) {
+	var params, oldParams map[string]json.RawMessage
+	if json.Unmarshal(c.Params, &params) != nil || json.Unmarshal(old.Params, &oldParams) != nil || params == nil {
+		return
+	}
+	for _, k := range secretParams {
+		if _, ok := params[k]; !ok && oldParams[k] != nil {
+			params[k] = oldParams[k]
+		}
+	}
+	c.Params, _ = json.Marshal(params)
+}
+
+const (
+	DeliverySent   = "sent"
+	DeliveryFailed = "failed"
+)
+
+// NotificationDelivery records one attempt to deliver an alert change to
+// a channel, including its retries.
+type NotificationDelivery struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ChannelID uint      `gorm:"not null;index" json:"channel_id"`
+	AlertID   uint      `gorm:"index" json:"alert_id"`
+	Event     string    `json:"event"`
+	Status    string    `gorm:"not null" json:"status"`
+	Attempts  int       `json:"attempts"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// SeverityRank orders severities from info (1) to critical (3); unknown
+// severities rank 0.
+func SeverityRank(severity string) int {
+	switch severity {
+	case SeverityInfo:
+		return 1
+	case SeverityWarning:
+		return 2
+	case SeverityCritical:
+		return 3
+	}
+	return 0
+}
@@ -0,0 +1,17 @@
+package domain
+
+// Availability summarises how long a device, or a group of devices, was up
+// within a report window. Durations are in seconds. Observed time excludes
+// periods before a device existed or whose status is unknown.
+type Availability struct {
+	ID              uint    `json:"id"`
+	Name            string  `json:"name"`
+	Devices         int     `json:"devices"`
+	ObservedSeconds float64 `json:"observed_seconds"`
+	UptimeSeconds   float64 `json:"uptime_seconds"`
+	DowntimeSeconds float64 `json:"downtime_seconds"`
+	UptimePct       float64 `json:"uptime_pct"`
+	Outages         int     `json:"outages"`
+	MTTRSeconds     float64 `json:"mttr_seconds"`
+	MTBFSeconds     float64 `json:"mtbf_seconds"`
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+const (
+	SNMPVersion2c = "2c"
+	SNMPVersion3  = "3"
+)
+
+// SNMPCredential holds how a device is polled over SNMP. Only devices with
+// a credential are polled.
+type SNMPCredential struct {
+	ID             uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	DeviceID       uint      `gorm:"not null;uniqueIndex" json:"device_id"`
+	Version        string    `gorm:"not null" json:"version"`
+	Port           int       `json:"port"`
+	Community      string    `json:"community,omitempty"`
+	Username       string    `json:"username"`
+	AuthProtocol   string    `json:"auth_protocol"` // MD5, SHA, SHA224, SHA256, SHA384, SHA512
+	AuthPassphrase string    `json:"auth_passphrase,omitempty"`
+	PrivProtocol   string    `json:"priv_protocol"` // DES, AES, AES192, AES256, AES192C, AES256C
+	PrivPassphrase string    `json:"priv_passphrase,omitempty"`
+	ContextName    string    `json:"context_name"`
+	Disabled       bool      `gorm:"not null;default:false" json:"disabled"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+	CreatedBy      string    `json:"created_by"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	UpdatedBy      string    `json:"updated_by"`
+}
+
+// Redacted returns a copy without secrets, for API responses.
+func (c SNMPCredential) Redacted() SNMPCredential {
+	c.Community = ""
+	c.AuthPassphrase = ""
+	c.PrivPassphrase = ""
+	return c
+}
+
+// SNMPOID is one value to poll. Walk polls every instance below OID, e.g.
+// one value per interface for IF-MIB columns.
+type SNMPOID struct {
+	Name string `json:"name"`
+	OID  string `json:"oid"`
+	Walk bool   `json:"walk"`
+}
+
+// SNMPOIDList is stored as a JSON array in a jsonb column.
+type SNMPOIDList []SNMPOID
+
+func (l SNMPOIDList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]SNMPOID(l))
+	return string(b), err
+}
+
+func (l *SNMPOIDList) Scan(src interface{}) error {
+	return scanJSON(src, (*[]SNMPOID)(l))
+}
+
+func (SNMPOIDList) GormDataType() string {
+	return "jsonb"
+}
+
+// OIDSet is a named group of OIDs, polled on every device whose type the
+// set is attached to.
+type OIDSet struct {
+	ID          uint        `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name        string      `gorm:"unique;not null" json:"name"`
+	Description string      `json:"description"`
+	OIDs        SNMPOIDList `gorm:"column:oids" json:"oids"`
+	CreatedAt   time.Time   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (OIDSet) TableName() string {
+	return "snmp_oid_sets"
+}
+
+type DeviceTypeOIDSet struct {
+	ID       uint `gorm:"primaryKey;autoIncrement"`
+	TypeID   uint `gorm:"not null;index"`
+	OIDSetID uint `gorm:"column:oid_set_id;not null;index"`
+}
+
+// SNMPMetric is one polled value. Instance is the index below a walked
+// OID (e.g. the ifIndex) and is empty for scalars. Text holds values that
+// are not numeric.
+type SNMPMetric struct {
+	ID       uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	DeviceID uint      `gorm:"not null;index:idx_snmp_metrics_series,priority:1" json:"device_id"`
+	Name     string    `gorm:"not null;index:idx_snmp_metrics_series,priority:2" json:"name"`
+	OID      string    `gorm:"column:oid;not null" json:"oid"`
+	Instance string    `json:"instance"`
+	Value    float64   `json:"value"`
+	Text     string    `json:"text,omitempty"`
+	PolledAt time.Time `gorm:"not null;index:idx_snmp_metrics_series,priority:3" json:"polled_at"`
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// StringList is a []string stored as a JSON array in a jsonb column.
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]string(l))
+	return string(b), err
+}
+
+func (l *StringList) Scan(src interface{}) error {
+	return scanJSON(src, (*[]string)(l))
+}
+
+func (StringList) GormDataType() string {
+	return "jsonb"
+}
+
+func scanJSON(src interface{}, dst interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dst)
+	case string:
+		return json.Unmarshal([]byte(v), dst)
+	}
+	return fmt.Errorf("cannot scan %T into %T", src, dst)
+}
+
+// UintList is a []uint stored as a JSON array in a jsonb column.
+type UintList []uint
+
+func (l UintList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]uint(l))
+	return string(b), err
+}
+
+func (l *UintList) Scan(src interface{}) error {
+	return scanJSON(src, (*[]uint)(l))
+}
+
+func (UintList) GormDataType() string {
+	return "jsonb"
+}
@@ -0,0 +1,168 @@
+package events
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+)
+
+const (
+	TypeDeviceAdded   = "device_added"
+	TypeDeviceUpdated = "device_updated"
+	TypeDeviceDeleted = "device_deleted"
+	TypeStatusChanged = "status_changed"
+)
+
+// Event is a change pushed to live subscribers. IDs increase
+// monotonically, also across restarts. Each instance numbers the events
+// its subscribers see itself.
+type Event struct {
+	ID        uint64         `json:"id"`
+	Type      string         `json:"type"`
+	DeviceID  uint           `json:"device_id,omitempty"`
+	Device    *domain.Device `json:"device,omitempty"`
+	OldStatus string         `json:"old_status,omitempty"`
+	NewStatus string         `json:"new_status,omitempty"`
+	Time      time.Time      `json:"time"`
+	// Totals is set per subscriber for its filtered set of devices.
+	Totals *Totals `json:"totals,omitempty"`
+}
+
+// Subscription receives events on C until it is unsubscribed. C is closed
+// when the subscriber falls too far behind, so it can reconnect instead of
+// silently missing events.
+type Subscription struct {
+	C <-chan Event
+	// LastID is the ID of the last event published before the
+	// subscription started.
+	LastID uint64
+	// Resumed is set when every event after the requested ID was still
+	// buffered. Those events are in Replay and precede the ones on C.
+	Resumed bool
+	Replay  []Event
+
+	c chan Event
+}
+
+// Hub fans events out to any number of subscribers and keeps the most
+// recent events so reconnecting subscribers can resume. Publish never
+// blocks.
+type Hub struct {
+	// Buffer is the number of events a subscriber may lag behind.
+	Buffer int
+	// ReplaySize is the number of recent events kept for resuming.
+	ReplaySize int
+	// Forward, when set, is also given every published event, e.g. to
+	// pass it on to other instances. It must not block.
+	Forward func(e Event)
+
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	lastID uint64
+	replay []Event
+}
+
+// NewHub keeps EVENT_REPLAY_SIZE (default 1000) events for resuming. IDs
+// start at the current time in microseconds so they keep increasing when
+// the process restarts.
+func NewHub() *Hub {
+	replaySize := 1000
+	if v, err := strconv.Atoi(os.Getenv("EVENT_REPLAY_SIZE")); err == nil && v >= 0 {
+		replaySize = v
+	}
+	return &Hub{
+		Buffer:     64,
+		ReplaySize: replaySize,
+		subs:       make(map[*Subscription]struct{}),
+		lastID:     uint64(time.Now().UnixMicro()),
+	}
+}
+
+func (h *Hub) Subscribe() *Subscription {
+	return h.SubscribeFrom(0)
+}
+
+// SubscribeFrom subscribes and, when since is non-zero, tries to resume
+// after the event with that ID.
+func (h *Hub) SubscribeFrom(since uint64) *Subscription {
+	c := make(chan Event, h.Buffer)
+	sub := &Subscription{C: c, c: c}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sub.LastID = h.lastID
+	switch {
+	case since == 0 || since > h.lastID:
+	case since == h.lastID:
+		sub.Resumed = true
+	case len(h.replay) > 0 && h.replay[0].ID <= since+1:
+		sub.Resumed = true
+		for _, e := range h.replay {
+			if e.ID > since {
+				sub.Replay = append(sub.Replay, e)
+			}
+		}
+	}
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe removes sub and closes its channel. It is safe to call more
+// than once.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.c)
+	}
+}
+
+// Publish assigns the next ID to e and sends it to all subscribers and
+// to Forward.
+func (h *Hub) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e = h.publish(e)
+	if h.Forward != nil {
+		h.Forward(e)
+	}
+}
+
+// Inject publishes an event that was forwarded by another instance. It
+// gets an ID of this hub and is not forwarded again.
+func (h *Hub) Inject(e Event) {
+	h.publish(e)
+}
+
+func (h *Hub) publish(e Event) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastID++
+	e.ID = h.lastID
+	if h.ReplaySize > 0 {
+		if len(h.replay) >= h.ReplaySize {
+			h.replay = append(h.replay[:0], h.replay[len(h.replay)-h.ReplaySize+1:]...)
+		}
+		h.replay = append(h.replay, e)
+	}
+	for sub := range h.subs {
+		select {
+		case sub.c <- e:
+		default:
+			delete(h.subs, sub)
+			close(sub.c)
+		}
+	}
+	return e
+}
+
+// Subscribers returns the number of active subscriptions.
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
@@ -0,0 +1,130 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// relayChannel is the Postgres notification channel events are sent on.
+const relayChannel = "netmon_events"
+
+// relayMessage is the payload of a notification. Origin tells instances
+// to skip the events they sent themselves.
+type relayMessage struct {
+	Origin string `json:"origin"`
+	Event  Event  `json:"event"`
+}
+
+// Relay passes the events published on a hub to the hubs of the other
+// instances using the same database, through Postgres LISTEN/NOTIFY, so
+// live clients see every change whichever instance they are connected
+// to. Events are best effort: those published while the listening
+// connection is down are not delivered.
+type Relay struct {
+	Hub *Hub
+	DB  *sql.DB
+
+	origin string
+	out    chan Event
+}
+
+// NewRelay forwards the events of hub once Run is started.
+func NewRelay(hub *Hub, db *sql.DB) *Relay {
+	b := make([]byte, 8)
+	rand.Read(b)
+	r := &Relay{Hub: hub, DB: db, origin: hex.EncodeToString(b), out: make(chan Event, 256)}
+	hub.Forward = r.forward
+	return r
+}
+
+// forward queues an event for sending, dropping it when the queue is
+// full so publishing never blocks.
+func (r *Relay) forward(e Event) {
+	select {
+	case r.out <- e:
+	default:
+		log.Printf("Event relay queue full, dropping event %d", e.ID)
+	}
+}
+
+// Run sends and receives events until ctx is cancelled, reconnecting when
+// the listening connection fails.
+func (r *Relay) Run(ctx context.Context) {
+	go r.send(ctx)
+	for {
+		err := r.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Event relay stopped listening: %v", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func (r *Relay) send(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-r.out:
+			b, err := json.Marshal(relayMessage{Origin: r.origin, Event: e})
+			if err != nil {
+				log.Printf("Error encoding event %d: %v", e.ID, err)
+				continue
+			}
+			// NOTIFY payloads are limited to 8000 bytes; larger events
+			// fail here and stay local.
+			if _, err := r.DB.ExecContext(ctx, "SELECT pg_notify($1, $2)", relayChannel, string(b)); err != nil {
+				log.Printf("Error relaying event %d: %v", e.ID, err)
+			}
+		}
+	}
+}
+
+// listen injects the events of other instances into the hub until the
+// connection fails or ctx is cancelled.
+func (r *Relay) listen(ctx context.Context) error {
+	conn, err := r.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.New("database driver does not support notifications")
+		}
+		pgConn := c.Conn()
+		if _, err := pgConn.Exec(ctx, "LISTEN "+relayChannel); err != nil {
+			return err
+		}
+		// Stop listening before the connection goes back to the pool.
+		defer pgConn.Exec(context.Background(), "UNLISTEN "+relayChannel)
+		for {
+			n, err := pgConn.WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+			var m relayMessage
+			if err := json.Unmarshal([]byte(n.Payload), &m); err != nil {
+				log.Printf("Error decoding relayed event: %v", err)
+				continue
+			}
+			if m.Origin != r.origin {
+				r.Hub.Inject(m.Event)
+			}
+		}
+	})
+}
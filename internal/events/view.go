@@ -0,0 +1,133 @@
+package events
+
+import (
+	"slices"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+)
+
+// Filter selects devices by location, type and status. Empty fields match
+// every device; a device matches TypeIDs when it has any of them.
+type Filter struct {
+	LocationIDs []uint
+	TypeIDs     []uint
+	Statuses    []string
+}
+
+func (f Filter) Match(device domain.Device) bool {
+	if len(f.LocationIDs) > 0 && !slices.Contains(f.LocationIDs, device.LocationID) {
+		return false
+	}
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, device.Status) {
+		return false
+	}
+	if len(f.TypeIDs) > 0 {
+		for _, id := range deviceTypeIDs(device) {
+			if slices.Contains(f.TypeIDs, id) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Apply returns the devices that match f.
+func (f Filter) Apply(devices []domain.Device) []domain.Device {
+	out := make([]domain.Device, 0, len(devices))
+	for _, d := range devices {
+		if f.Match(d) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+type Totals struct {
+	Total   int `json:"total"`
+	Online  int `json:"online"`
+	Offline int `json:"offline"`
+}
+
+// CountTotals counts devices the way the live endpoints always have:
+// everything that is not online counts as offline.
+func CountTotals(devices []domain.Device) Totals {
+	t := Totals{Total: len(devices)}
+	for _, d := range devices {
+		if d.Status == domain.StatusOnline {
+			t.Online++
+		}
+	}
+	t.Offline = t.Total - t.Online
+	return t
+}
+
+// View tracks the devices one subscriber sees so events can be turned into
+// deltas for its filter.
+type View struct {
+	Filter  Filter
+	devices map[uint]string // device ID -> status
+}
+
+// NewView starts a view from a snapshot of all devices.
+func NewView(f Filter, devices []domain.Device) *View {
+	v := &View{Filter: f, devices: make(map[uint]string)}
+	for _, d := range f.Apply(devices) {
+		v.devices[d.ID] = d.Status
+	}
+	return v
+}
+
+// Apply translates e for the view and reports whether it should be sent.
+// Events of devices outside the filter are dropped; a device that starts
+// matching is sent as device_added and one that stops matching as
+// device_deleted. The returned event carries the
+// totals of the filtered set.
+func (v *View) Apply(e Event) (Event, bool) {
+	if e.Device == nil {
+		return e, false
+	}
+	_, visible := v.devices[e.DeviceID]
+	switch {
+	case e.Type == TypeDeviceDeleted:
+		if !visible {
+			return e, false
+		}
+		delete(v.devices, e.DeviceID)
+	case v.Filter.Match(*e.Device):
+		v.devices[e.DeviceID] = e.Device.Status
+		if !visible {
+			e.Type = TypeDeviceAdded
+		}
+	case visible:
+		delete(v.devices, e.DeviceID)
+		e.Type = TypeDeviceDeleted
+	default:
+		return e, false
+	}
+	totals := v.Totals()
+	e.Totals = &totals
+	return e, true
+}
+
+func (v *View) Totals() Totals {
+	t := Totals{Total: len(v.devices)}
+	for _, status := range v.devices {
+		if status == domain.StatusOnline {
+			t.Online++
+		}
+	}
+	t.Offline = t.Total - t.Online
+	return t
+}
+
+func deviceTypeIDs(device domain.Device) []uint {
+	if len(device.TypeIDs) > 0 {
+		return device.TypeIDs
+	}
+	ids := make([]uint, 0, len(device.Types))
+	for _, t := range device.Types {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
@@ -0,0 +1,130 @@
+// Package leader elects one of several backend instances sharing a
+// database to run the work that must not run twice, such as probing.
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"log"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// defaultLockKey is the advisory lock taken when LEADER_LOCK_KEY is not
+// set. Instances only compete with others using the same key.
+const defaultLockKey = 7311842
+
+// Elector makes the instance that holds a Postgres session-level advisory
+// lock the leader. The lock lives on a dedicated connection, so it is
+// released by Postgres when the leader dies or loses its connection; the
+// leader notices the latter within Retry and stops leading.
+type Elector struct {
+	DB    *sql.DB
+	Key   int64
+	Retry time.Duration // how often followers try the lock and the leader checks its connection
+
+	leading atomic.Bool
+}
+
+// New reads LEADER_LOCK_KEY and LEADER_RETRY (default 5s).
+func New(db *sql.DB) *Elector {
+	e := &Elector{DB: db, Key: defaultLockKey, Retry: 5 * time.Second}
+	if v, err := strconv.ParseInt(os.Getenv("LEADER_LOCK_KEY"), 10, 64); err == nil {
+		e.Key = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("LEADER_RETRY")); err == nil && v > 0 {
+		e.Retry = v
+	}
+	return e
+}
+
+// IsLeader reports whether this instance is leading right now.
+func (e *Elector) IsLeader() bool {
+	return e.leading.Load()
+}
+
+// Run competes for leadership until ctx is cancelled. Whenever this
+// instance becomes leader it calls lead with a context that is cancelled
+// when leadership ends, and waits for lead to return before competing
+// again.
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	for {
+		if conn := e.acquire(ctx); conn != nil {
+			e.hold(ctx, conn, lead)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.Retry):
+		}
+	}
+}
+
+// acquire returns the connection holding the lock, or nil when another
+// instance holds it.
+func (e *Elector) acquire(ctx context.Context) *sql.Conn {
+	conn, err := e.DB.Conn(ctx)
+	if err != nil {
+		log.Printf("Error connecting for leader election: %v", err)
+		return nil
+	}
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", e.Key).Scan(&ok); err != nil || !ok {
+		if err != nil {
+			log.Printf("Error trying leader lock: %v", err)
+		}
+		conn.Close()
+		return nil
+	}
+	return conn
+}
+
+// hold leads while the lock's connection stays healthy, then releases the
+// lock.
+func (e *Elector) hold(ctx context.Context, conn *sql.Conn, lead func(ctx context.Context)) {
+	log.Println("Elected leader")
+	e.leading.Store(true)
+	leadCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lead(leadCtx)
+	}()
+
+	ticker := time.NewTicker(e.Retry)
+	defer ticker.Stop()
+wait:
+	for {
+		select {
+		case <-ctx.Done():
+			break wait
+		case <-ticker.C:
+			pingCtx, cancelPing := context.WithTimeout(ctx, e.Retry)
+			err := conn.PingContext(pingCtx)
+			cancelPing()
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Lost leader lock: %v", err)
+				break wait
+			}
+		}
+	}
+	cancel()
+	<-done
+	e.leading.Store(false)
+	log.Println("Stopped leading")
+	e.release(conn)
+}
+
+// release unlocks and returns the connection to the pool. When unlocking
+// fails the connection is discarded instead, which also drops the lock.
+func (e *Elector) release(conn *sql.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.Retry)
+	defer cancel()
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", e.Key); err != nil {
+		conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	}
+	conn.Close()
+}
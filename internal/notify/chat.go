@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Chat posts to a Slack or Mattermost incoming webhook.
+type Chat struct {
+	URL      string `json:"url"`
+	Channel  string `json:"channel"`  // overrides the webhook's channel when allowed
+	Username string `json:"username"` // overrides the webhook's user name when allowed
+
+	Client *http.Client `json:"-"`
+}
+
+func (c *Chat) validate() error {
+	if c.URL == "" {
+		return errors.New("url is required")
+	}
+	return nil
+}
+
+func (c *Chat) Send(ctx context.Context, msg Message) error {
+	payload := map[string]string{"text": chatText(msg)}
+	if c.Channel != "" {
+		payload["channel"] = c.Channel
+	}
+	if c.Username != "" {
+		payload["username"] = c.Username
+	}
+	return postJSON(ctx, c.Client, c.URL, payload, nil)
+}
+
+// Telegram sends through the Bot API. APIURL defaults to
+// https://api.telegram.org.
+type Telegram struct {
+	Token  string `json:"token"`
+	ChatID string `json:"chat_id"`
+	APIURL string `json:"api_url"`
+
+	Client *http.Client `json:"-"`
+}
+
+func (t *Telegram) validate() error {
+	if t.Token == "" || t.ChatID == "" {
+		return errors.New("token and chat_id are required")
+	}
+	return nil
+}
+
+func (t *Telegram) Send(ctx context.Context, msg Message) error {
+	api := t.APIURL
+	if api == "" {
+		api = "https://api.telegram.org"
+	}
+	endpoint := strings.TrimSuffix(api, "/") + "/bot" + t.Token + "/sendMessage"
+	return postJSON(ctx, t.Client, endpoint, map[string]string{
+		"chat_id": t.ChatID,
+		"text":    chatText(msg),
+	}, nil)
+}
+
+func chatText(msg Message) string {
+	if msg.Text == "" {
+		return msg.Subject
+	}
+	return msg.Subject + "\n" + msg.Text
+}
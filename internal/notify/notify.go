@@ -0,0 +1,209 @@
+// Package notify delivers alert messages to external services.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"text/template"
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+)
+
+const (
+	TypeWebhook    = "webhook"
+	TypeEmail      = "email"
+	TypeSlack      = "slack"
+	TypeMattermost = "mattermost"
+	TypeTelegram   = "telegram"
+)
+
+// Message is a rendered notification about an alert.
+type Message struct {
+	Event   string       `json:"event"` // the alert state that triggered it
+	Subject string       `json:"subject"`
+	Text    string       `json:"text"`
+	Alert   domain.Alert `json:"alert"`
+}
+
+// Notifier sends messages to one destination. Send must return once ctx is
+// done.
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New builds the notifier for a channel type from its JSON params.
+func New(channelType string, params json.RawMessage) (Notifier, error) {
+	var n Notifier
+	switch channelType {
+	case TypeWebhook:
+		n = &Webhook{}
+	case TypeEmail:
+		n = &Email{}
+	case TypeSlack, TypeMattermost:
+		n = &Chat{}
+	case TypeTelegram:
+		n = &Telegram{}
+	default:
+		return nil, fmt.Errorf("unknown channel type %q", channelType)
+	}
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, n); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+	if v, ok := n.(interface{ validate() error }); ok {
+		if err := v.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+const (
+	DefaultSubject = `[{{.Alert.Severity}}] {{if .Alert.RuleName}}{{.Alert.RuleName}}{{else}}Alert {{.Alert.ID}}{{end}} {{.Event}}`
+	DefaultBody    = `{{.Alert.Message}}
+State: {{.Event}}
+Started: {{.Alert.StartedAt.Format "2006-01-02 15:04:05 MST"}}`
+)
+
+// Template renders the subject and text of messages. Templates use
+// text/template syntax with .Event and .Alert.
+type Template struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+// ParseTemplate parses subject and body, using the defaults for empty
+// ones.
+func ParseTemplate(subject, body string) (*Template, error) {
+	if subject == "" {
+		subject = DefaultSubject
+	}
+	if body == "" {
+		body = DefaultBody
+	}
+	s, err := template.New("subject").Parse(subject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subject template: %w", err)
+	}
+	b, err := template.New("body").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body template: %w", err)
+	}
+	return &Template{subject: s, body: b}, nil
+}
+
+func (t *Template) Render(event string, alert domain.Alert) (Message, error) {
+	data := struct {
+		Event string
+		Alert domain.Alert
+	}{event, alert}
+	var subject, body bytes.Buffer
+	if err := t.subject.Execute(&subject, data); err != nil {
+		return Message{}, err
+	}
+	if err := t.body.Execute(&body, data); err != nil {
+		return Message{}, err
+	}
+	return Message{Event: event, Subject: subject.String(), Text: body.String(), Alert: alert}, nil
+}
+
+// Retry is how often and how patiently a message is sent.
+type Retry struct {
+	Attempts int
+	Backoff  time.Duration // doubled after every failed attempt
+}
+
+// Deliver sends msg, retrying failed attempts with exponential backoff.
+// Errors marked permanent, such as a 4xx response, are not retried. It
+// returns the number of attempts made and the last error.
+func Deliver(ctx context.Context, n Notifier, msg Message, r Retry) (int, error) {
+	backoff := r.Backoff
+	var err error
+	attempt := 0
+	for attempt < max(r.Attempts, 1) {
+		attempt++
+		if err = n.Send(ctx, msg); err == nil {
+			return attempt, nil
+		}
+		var perm *permanentError
+		if errors.As(err, &perm) || attempt >= r.Attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return attempt, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return attempt, err
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// postJSON posts v to endpoint. 4xx responses other than 429 are
+// permanent errors.
+func postJSON(ctx context.Context, client *http.Client, endpoint string, v interface{}, header http.Header) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return &permanentError{err}
+	}
+	return post(ctx, client, endpoint, body, header)
+}
+
+// post sends body to endpoint. Errors do not include endpoint, which may
+// hold a secret such as a bot token, as they are stored with deliveries.
+func post(ctx context.Context, client *http.Client, endpoint string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{stripURL(err)}
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if client == nil {
+		client = defaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return stripURL(err)
+	}
+	defer resp.Body.Close()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(snippet))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return &permanentError{err}
+	}
+	return err
+}
+
+// stripURL returns the error wrapped by a *url.Error, dropping the URL it
+// mentions.
+func stripURL(err error) error {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		return fmt.Errorf("%s request: %w", uerr.Op, uerr.Err)
+	}
+	return err
+}
+
+var defaultClient = &http.Client{Timeout: 15 * time.Second}
@@ -0,0 +1,351 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+)
+
+var testMessage = Message{
+	Event:   domain.AlertFiring,
+	Subject: "[critical] Router down firing",
+	Text:    "Device router is offline\nState: firing",
+	Alert:   domain.Alert{ID: 7, RuleName: "Router down", Severity: domain.SeverityCritical},
+}
+
+// request is what a stand-in server received.
+type request struct {
+	path   string
+	header http.Header
+	body   []byte
+}
+
+// recorder starts a server that answers every request with status and
+// records the requests it gets.
+func recorder(t *testing.T, status int) (*httptest.Server, func() []request) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, request{path: r.URL.Path, header: r.Header.Clone(), body: body})
+		mu.Unlock()
+		w.WriteHeader(status)
+		io.WriteString(w, http.StatusText(status))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []request {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]request(nil), requests...)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	srv, requests := recorder(t, http.StatusNoContent)
+	w := &Webhook{URL: srv.URL + "/hook", Secret: "s3cret", Headers: map[string]string{"X-Team": "ops"}}
+	if err := w.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	reqs := requests()
+	if len(reqs) != 1 {
+		t.Fatalf("got %d requests, want 1", len(reqs))
+	}
+	req := reqs[0]
+	if req.path != "/hook" {
+		t.Errorf("path = %q", req.path)
+	}
+	if got := req.header.Get("X-Team"); got != "ops" {
+		t.Errorf("X-Team = %q", got)
+	}
+	if got := req.header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	ts := req.header.Get("X-Netmon-Timestamp")
+	if ts == "" {
+		t.Fatal("X-Netmon-Timestamp is missing")
+	}
+	if got, want := req.header.Get("X-Netmon-Signature"), Sign("s3cret", ts, req.body); got != want {
+		t.Errorf("X-Netmon-Signature = %q, want %q", got, want)
+	}
+	var msg Message
+	if err := json.Unmarshal(req.body, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Subject != testMessage.Subject || msg.Alert.ID != testMessage.Alert.ID {
+		t.Errorf("body = %+v", msg)
+	}
+}
+
+func TestWebhookWithoutSecret(t *testing.T) {
+	srv, requests := recorder(t, http.StatusOK)
+	if err := (&Webhook{URL: srv.URL}).Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if sig := requests()[0].header.Get("X-Netmon-Signature"); sig != "" {
+		t.Errorf("unsigned webhook sent X-Netmon-Signature %q", sig)
+	}
+}
+
+func TestChat(t *testing.T) {
+	srv, requests := recorder(t, http.StatusOK)
+	c := &Chat{URL: srv.URL, Channel: "#alerts", Username: "netmon"}
+	if err := c.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal(requests()[0].body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"text":     testMessage.Subject + "\n" + testMessage.Text,
+		"channel":  "#alerts",
+		"username": "netmon",
+	}
+	for k, v := range want {
+		if payload[k] != v {
+			t.Errorf("%s = %q, want %q", k, payload[k], v)
+		}
+	}
+}
+
+func TestTelegram(t *testing.T) {
+	srv, requests := recorder(t, http.StatusOK)
+	tg := &Telegram{Token: "123:abc", ChatID: "-100", APIURL: srv.URL + "/"}
+	if err := tg.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	req := requests()[0]
+	if req.path != "/bot123:abc/sendMessage" {
+		t.Errorf("path = %q", req.path)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload["chat_id"] != "-100" || payload["text"] != testMessage.Subject+"\n"+testMessage.Text {
+		t.Errorf("payload = %v", payload)
+	}
+}
+
+func TestTelegramErrorHidesToken(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	tg := &Telegram{Token: "123:secret-token", ChatID: "-100", APIURL: srv.URL}
+	err := tg.Send(context.Background(), testMessage)
+	if err == nil {
+		t.Fatal("send to a closed server succeeded")
+	}
+	if strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("error leaks the token: %v", err)
+	}
+}
+
+func TestEmail(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	received := make(chan smtpSession, 1)
+	go serveSMTP(ln, received)
+
+	e := &Email{
+		Host: "127.0.0.1",
+		Port: ln.Addr().(*net.TCPAddr).Port,
+		From: "netmon@example.com",
+		To:   []string{"ops@example.com", "oncall@example.com"},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.Send(ctx, testMessage); err != nil {
+		t.Fatal(err)
+	}
+
+	s := <-received
+	if s.from != "<netmon@example.com>" {
+		t.Errorf("MAIL FROM %q", s.from)
+	}
+	if strings.Join(s.to, ",") != "<ops@example.com>,<oncall@example.com>" {
+		t.Errorf("RCPT TO %q", s.to)
+	}
+	for _, want := range []string{
+		"From: netmon@example.com\r\n",
+		"To: ops@example.com, oncall@example.com\r\n",
+		"Subject: " + testMessage.Subject + "\r\n",
+		"\r\n\r\nDevice router is offline\r\nState: firing\r\n",
+	} {
+		if !strings.Contains(s.data, want) {
+			t.Errorf("message lacks %q:\n%s", want, s.data)
+		}
+	}
+}
+
+// smtpSession is what the fake SMTP server received.
+type smtpSession struct {
+	from string
+	to   []string
+	data string
+}
+
+// serveSMTP accepts one connection and speaks just enough SMTP for Email.
+func serveSMTP(ln net.Listener, received chan<- smtpSession) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	var s smtpSession
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(cmd) {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "MAIL":
+			s.from = strings.TrimPrefix(arg, "FROM:")
+			if i := strings.Index(s.from, " "); i >= 0 {
+				s.from = s.from[:i]
+			}
+			reply("250 OK")
+		case "RCPT":
+			s.to = append(s.to, strings.TrimPrefix(arg, "TO:"))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.data = data.String()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			received <- s
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// flaky fails with errs in turn, then succeeds.
+type flaky struct {
+	errs  []error
+	calls []time.Time
+}
+
+func (f *flaky) Send(ctx context.Context, msg Message) error {
+	f.calls = append(f.calls, time.Now())
+	if len(f.calls) <= len(f.errs) {
+		return f.errs[len(f.calls)-1]
+	}
+	return nil
+}
+
+func TestDeliverRetriesWithBackoff(t *testing.T) {
+	fail := errors.New("connection refused")
+	n := &flaky{errs: []error{fail, fail}}
+	attempts, err := Deliver(context.Background(), n, testMessage, Retry{Attempts: 4, Backoff: 20 * time.Millisecond})
+	if err != nil || attempts != 3 {
+		t.Fatalf("Deliver = %d, %v; want 3, nil", attempts, err)
+	}
+	if gap := n.calls[1].Sub(n.calls[0]); gap < 20*time.Millisecond {
+		t.Errorf("first retry after %s, want at least 20ms", gap)
+	}
+	if gap := n.calls[2].Sub(n.calls[1]); gap < 40*time.Millisecond {
+		t.Errorf("second retry after %s, want at least 40ms", gap)
+	}
+}
+
+func TestDeliverGivesUp(t *testing.T) {
+	fail := errors.New("connection refused")
+	n := &flaky{errs: []error{fail, fail, fail, fail}}
+	attempts, err := Deliver(context.Background(), n, testMessage, Retry{Attempts: 3, Backoff: time.Millisecond})
+	if !errors.Is(err, fail) || attempts != 3 {
+		t.Fatalf("Deliver = %d, %v; want 3, %v", attempts, err, fail)
+	}
+}
+
+func TestDeliverStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	n := &flaky{errs: []error{errors.New("timeout"), errors.New("timeout")}}
+	attempts, err := Deliver(ctx, n, testMessage, Retry{Attempts: 3, Backoff: time.Hour})
+	if err == nil || attempts != 1 {
+		t.Fatalf("Deliver = %d, %v; want 1 and an error", attempts, err)
+	}
+}
+
+func TestDeliverHTTPStatus(t *testing.T) {
+	tests := []struct {
+		status   int
+		attempts int
+	}{
+		{http.StatusBadRequest, 1},
+		{http.StatusNotFound, 1},
+		{http.StatusTooManyRequests, 3},
+		{http.StatusBadGateway, 3},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			srv, requests := recorder(t, tt.status)
+			attempts, err := Deliver(context.Background(), &Chat{URL: srv.URL}, testMessage, Retry{Attempts: 3, Backoff: time.Millisecond})
+			if err == nil {
+				t.Fatal("Deliver succeeded")
+			}
+			if attempts != tt.attempts || len(requests()) != tt.attempts {
+				t.Errorf("%d attempts and %d requests, want %d", attempts, len(requests()), tt.attempts)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		typ    string
+		params string
+		ok     bool
+	}{
+		{TypeWebhook, `{"url":"http://example.com"}`, true},
+		{TypeWebhook, `{}`, false},
+		{TypeSlack, `{"url":"http://example.com"}`, true},
+		{TypeTelegram, `{"token":"t"}`, false},
+		{TypeEmail, `{"host":"mail","from":"a@b","to":["c@d"]}`, true},
+		{TypeEmail, `{"host":"mail"}`, false},
+		{"pager", `{}`, false},
+	}
+	for _, tt := range tests {
+		if _, err := New(tt.typ, json.RawMessage(tt.params)); (err == nil) != tt.ok {
+			t.Errorf("New(%s, %s) = %v", tt.typ, tt.params, err)
+		}
+	}
+}
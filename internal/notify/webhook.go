@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Webhook posts the message as JSON to URL. With a Secret, every request
+// carries X-Netmon-Timestamp and X-Netmon-Signature headers; the signature
+// is "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>".
+type Webhook struct {
+	URL     string            `json:"url"`
+	Secret  string            `json:"secret"`
+	Headers map[string]string `json:"headers"`
+
+	Client *http.Client `json:"-"`
+}
+
+func (w *Webhook) validate() error {
+	if w.URL == "" {
+		return errors.New("url is required")
+	}
+	return nil
+}
+
+func (w *Webhook) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return &permanentError{err}
+	}
+	header := make(http.Header)
+	for k, v := range w.Headers {
+		header.Set(k, v)
+	}
+	if w.Secret != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		header.Set("X-Netmon-Timestamp", ts)
+		header.Set("X-Netmon-Signature", Sign(w.Secret, ts, body))
+	}
+	return post(ctx, w.Client, w.URL, body, header)
+}
+
+// Sign returns the X-Netmon-Signature value for a webhook body.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
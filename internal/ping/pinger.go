@@ -0,0 +1,267 @@
+package ping
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	protocolICMP     = 1
+	protocolIPv6ICMP = 58
+)
+
+// MaxSize is the largest payload an echo request can carry in an IPv4
+// packet. Payloads above the path MTU are fragmented.
+const MaxSize = 65507
+
+// Pinger sends ICMP echo requests without shelling out to the ping binary.
+// It uses unprivileged datagram sockets where the kernel allows them
+// (net.ipv4.ping_group_range on Linux) and falls back to raw sockets.
+type Pinger struct {
+	Count      int           // echo requests per Ping
+	Interval   time.Duration // wait between echo requests
+	Timeout    time.Duration // wait for each echo reply
+	Size       int           // payload size in bytes
+	Privileged bool          // skip the datagram socket and use a raw socket
+}
+
+type Result struct {
+	Addr     string        `json:"addr"`
+	Sent     int           `json:"sent"`
+	Received int           `json:"received"`
+	Loss     float64       `json:"loss"` // percent
+	MinRTT   time.Duration `json:"min_rtt"`
+	AvgRTT   time.Duration `json:"avg_rtt"`
+	MaxRTT   time.Duration `json:"max_rtt"`
+}
+
+// Alive reports whether at least one echo reply was received.
+func (r *Result) Alive() bool {
+	return r.Received > 0
+}
+
+// NewPingerFromEnv reads ICMP_COUNT, ICMP_INTERVAL, ICMP_TIMEOUT, ICMP_SIZE
+// and ICMP_PRIVILEGED, falling back to defaults for missing values.
+func NewPingerFromEnv() *Pinger {
+	p := &Pinger{
+		Count:    3,
+		Interval: 200 * time.Millisecond,
+		Timeout:  time.Second,
+		Size:     56,
+	}
+	if v, err := strconv.Atoi(os.Getenv("ICMP_COUNT")); err == nil && v > 0 {
+		p.Count = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("ICMP_INTERVAL")); err == nil && v >= 0 {
+		p.Interval = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("ICMP_TIMEOUT")); err == nil && v > 0 {
+		p.Timeout = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("ICMP_SIZE")); err == nil && v >= 0 && v <= MaxSize {
+		p.Size = v
+	}
+	if v, err := strconv.ParseBool(os.Getenv("ICMP_PRIVILEGED")); err == nil {
+		p.Privileged = v
+	}
+	return p
+}
+
+// Ping resolves host and sends Count echo requests to it. An error is only
+// returned when the host cannot be resolved or no socket can be opened;
+// unanswered requests are reported as loss.
+func (p *Pinger) Ping(ctx context.Context, host string) (*Result, error) {
+	ip, err := resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	isV4 := ip.To4() != nil
+
+	conn, raw, err := listen(isV4, p.Privileged)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var dst net.Addr = &net.UDPAddr{IP: ip}
+	if raw {
+		dst = &net.IPAddr{IP: ip}
+	}
+
+	count := p.Count
+	if count <= 0 {
+		count = 1
+	}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	var idBuf [2]byte
+	rand.Read(idBuf[:])
+	id := int(binary.BigEndian.Uint16(idBuf[:]))
+	payload := make([]byte, max(p.Size, 0))
+	rand.Read(payload)
+
+	result := &Result{Addr: ip.String()}
+	var total time.Duration
+	for seq := 0; seq < count; seq++ {
+		if seq > 0 && p.Interval > 0 {
+			select {
+			case <-ctx.Done():
+				return result.finish(total), nil
+			case <-time.After(p.Interval):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		rtt, err := echo(ctx, conn, dst, isV4, raw, id, seq, payload, timeout)
+		result.Sent++
+		if err != nil {
+			continue
+		}
+		result.Received++
+		total += rtt
+		if result.MinRTT == 0 || rtt < result.MinRTT {
+			result.MinRTT = rtt
+		}
+		if rtt > result.MaxRTT {
+			result.MaxRTT = rtt
+		}
+	}
+	return result.finish(total), nil
+}
+
+func (r *Result) finish(total time.Duration) *Result {
+	if r.Received > 0 {
+		r.AvgRTT = total / time.Duration(r.Received)
+	}
+	if r.Sent > 0 {
+		r.Loss = float64(r.Sent-r.Received) / float64(r.Sent) * 100
+	}
+	return r
+}
+
+func echo(ctx context.Context, conn *icmp.PacketConn, dst net.Addr, isV4, raw bool, id, seq int, payload []byte, timeout time.Duration) (time.Duration, error) {
+	var typ icmp.Type = ipv4.ICMPTypeEcho
+	proto := protocolICMP
+	if !isV4 {
+		typ = ipv6.ICMPTypeEchoRequest
+		proto = protocolIPv6ICMP
+	}
+	msg := icmp.Message{
+		Type: typ,
+		Body: &icmp.Echo{ID: id, Seq: seq, Data: payload},
+	}
+	b, err := msg.Marshal(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	if _, err := conn.WriteTo(b, dst); err != nil {
+		return 0, err
+	}
+
+	// Replies echo the payload, so the buffer must hold at least the
+	// request, plus an IP header on raw sockets.
+	buf := make([]byte, max(len(b)+60, 1500))
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			return 0, err
+		}
+		rtt := time.Since(start)
+		if !sameHost(peer, dst) {
+			continue
+		}
+		reply, err := icmp.ParseMessage(proto, buf[:n])
+		if err != nil {
+			continue
+		}
+		if reply.Type != ipv4.ICMPTypeEchoReply && reply.Type != ipv6.ICMPTypeEchoReply {
+			continue
+		}
+		body, ok := reply.Body.(*icmp.Echo)
+		if !ok || body.Seq != seq || !bytes.Equal(body.Data, payload) {
+			continue
+		}
+		// Datagram sockets get their echo ID rewritten by the kernel, so
+		// the ID can only be matched on raw sockets.
+		if raw && body.ID != id {
+			continue
+		}
+		return rtt, nil
+	}
+}
+
+func listen(isV4, privileged bool) (*icmp.PacketConn, bool, error) {
+	network, address, rawNetwork := "udp4", "0.0.0.0", "ip4:icmp"
+	if !isV4 {
+		network, address, rawNetwork = "udp6", "::", "ip6:ipv6-icmp"
+	}
+	if !privileged {
+		if conn, err := icmp.ListenPacket(network, address); err == nil {
+			return conn, false, nil
+		}
+	}
+	conn, err := icmp.ListenPacket(rawNetwork, address)
+	if err != nil {
+		return nil, false, fmt.Errorf("open icmp socket: %w", err)
+	}
+	return conn, true, nil
+}
+
+func resolve(ctx context.Context, host string) (net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip, nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			return addr.IP, nil
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, errors.New("no addresses found for " + host)
+	}
+	return addrs[0].IP, nil
+}
+
+func sameHost(a, b net.Addr) bool {
+	return hostIP(a).Equal(hostIP(b))
+}
+
+func hostIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		return a.IP
+	case *net.IPAddr:
+		return a.IP
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AgentRepository struct {
+	DB *gorm.DB
+}
+
+func NewAgentRepository(db *gorm.DB) *AgentRepository {
+	return &AgentRepository{DB: db}
+}
+
+func (r *AgentRepository) CreateAgent(a *domain.Agent) error {
+	return r.DB.Create(a).Error
+}
+
+func (r *AgentRepository) UpdateAgent(a *domain.Agent) error {
+	return r.DB.Model(&domain.Agent{}).Where("id = ?", a.ID).
+		Select("name", "description", "device_ids", "location_ids", "disabled", "updated_by").
+		Updates(a).Error
+}
+
+// UpdateAgentFields updates the given columns of an agent, such as its
+// token hash or when it was last seen.
+func (r *AgentRepository) UpdateAgentFields(id uint, fields map[string]interface{}) error {
+	return r.DB.Model(&domain.Agent{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *AgentRepository) GetAllAgents() ([]domain.Agent, error) {
+	var agents []domain.Agent
+	if err := r.DB.Order("id ASC").Find(&agents).Error; err != nil {
+		return nil, err
+	}
+	return agents, nil
+}
+
+func (r *AgentRepository) GetAgentByID(id uint) (*domain.Agent, error) {
+	var a domain.Agent
+	if err := r.DB.First(&a, id).Error; err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *AgentRepository) GetAgentByTokenHash(hash string) (*domain.Agent, error) {
+	var a domain.Agent
+	if err := r.DB.Where("token_hash = ?", hash).First(&a).Error; err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// DeleteAgent deletes an agent with its results.
+func (r *AgentRepository) DeleteAgent(id uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("agent_id = ?", id).Delete(&domain.AgentResult{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.Agent{}, id).Error
+	})
+}
+
+// UpsertResults stores the latest results of an agent, replacing the
+// previous ones for the same devices.
+func (r *AgentRepository) UpsertResults(results []domain.AgentResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "agent_id"}, {Name: "device_id"}},
+		UpdateAll: true,
+	}).Create(&results).Error
+}
+
+func (r *AgentRepository) GetResultsByDevice(deviceID uint) ([]domain.AgentResult, error) {
+	var results []domain.AgentResult
+	if err := r.DB.Where("device_id = ?", deviceID).Order("agent_id ASC").Find(&results).Error; err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *AgentRepository) GetResultsByAgent(agentID uint) ([]domain.AgentResult, error) {
+	var results []domain.AgentResult
+	if err := r.DB.Where("agent_id = ?", agentID).Order("device_id ASC").Find(&results).Error; err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *AgentRepository) DeleteResultsByDevice(deviceID uint) error {
+	return r.DB.Where("device_id = ?", deviceID).Delete(&domain.AgentResult{}).Error
+}
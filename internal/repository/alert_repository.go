@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"gorm.io/gorm"
+)
+
+type AlertRepository struct {
+	DB *gorm.DB
+}
+
+func NewAlertRepository(db *gorm.DB) *AlertRepository {
+	return &AlertRepository{DB: db}
+}
+
+func (r *AlertRepository) CreateRule(rule *domain.AlertRule) error {
+	return r.DB.Create(rule).Error
+}
+
+func (r *AlertRepository) UpdateRule(rule *domain.AlertRule) error {
+	return r.DB.Model(&domain.AlertRule{}).Where("id = ?", rule.ID).
+		Select("name", "condition", "threshold", "for_minutes", "check_type", "severity", "device_ids", "type_ids", "location_ids", "escalation_policy_id", "disabled", "updated_by").
+		Updates(rule).Error
+}
+
+func (r *AlertRepository) GetAllRules() ([]domain.AlertRule, error) {
+	var rules []domain.AlertRule
+	if err := r.DB.Order("id ASC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *AlertRepository) GetRuleByID(id uint) (*domain.AlertRule, error) {
+	var rule domain.AlertRule
+	if err := r.DB.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *AlertRepository) DeleteRule(id uint) error {
+	return r.DB.Delete(&domain.AlertRule{}, id).Error
+}
+
+func (r *AlertRepository) CreateAlert(alert *domain.Alert) error {
+	return r.DB.Create(alert).Error
+}
+
+func (r *AlertRepository) UpdateAlert(id uint, fields map[string]interface{}) error {
+	return r.DB.Model(&domain.Alert{}).Where("id = ?", id).Updates(fields).Error
+}
+
+// GetOpenAlerts returns every alert that is firing or acknowledged.
+func (r *AlertRepository) GetOpenAlerts() ([]domain.Alert, error) {
+	var alerts []domain.Alert
+	if err := r.alertQuery().Where("alerts.state <> ?", domain.AlertResolved).Find(&alerts).Error; err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+func (r *AlertRepository) GetAlertByID(id uint) (*domain.Alert, error) {
+	var alert domain.Alert
+	if err := r.alertQuery().Where("alerts.id = ?", id).First(&alert).Error; err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// AlertFilter selects alerts. Zero values are not applied.
+type AlertFilter struct {
+	States   []string
+	DeviceID uint
+	RuleID   uint
+	Limit    int
+}
+
+// GetAlerts returns the newest alerts matching f with rule and device
+// names.
+func (r *AlertRepository) GetAlerts(f AlertFilter) ([]domain.Alert, error) {
+	q := r.alertQuery()
+	if len(f.States) > 0 {
+		q = q.Where("alerts.state IN ?", f.States)
+	}
+	if f.DeviceID != 0 {
+		q = q.Where("alerts.device_id = ?", f.DeviceID)
+	}
+	if f.RuleID != 0 {
+		q = q.Where("alerts.rule_id = ?", f.RuleID)
+	}
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	var alerts []domain.Alert
+	if err := q.Order("alerts.started_at DESC, alerts.id DESC").Find(&alerts).Error; err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+func (r *AlertRepository) alertQuery() *gorm.DB {
+	return r.DB.Model(&domain.Alert{}).
+		Select("alerts.*, alert_rules.name AS rule_name, devices.name AS device_name").
+		Joins("LEFT JOIN alert_rules ON alert_rules.id = alerts.rule_id").
+		Joins("LEFT JOIN devices ON devices.id = alerts.device_id")
+}
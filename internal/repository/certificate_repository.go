@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CertificateRepository struct {
+	DB *gorm.DB
+}
+
+func NewCertificateRepository(db *gorm.DB) *CertificateRepository {
+	return &CertificateRepository{DB: db}
+}
+
+// UpsertCertificate stores the certificate seen on a device endpoint,
+// replacing the previous one for the same device, host and port.
+func (r *CertificateRepository) UpsertCertificate(cert *domain.Certificate) error {
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "device_id"}, {Name: "host"}, {Name: "port"}},
+		UpdateAll: true,
+	}).Create(cert).Error
+}
+
+// GetCertificatesExpiringBefore returns certificates whose chain expires
+// before t, soonest first, with the device name filled in.
+func (r *CertificateRepository) GetCertificatesExpiringBefore(t time.Time) ([]domain.Certificate, error) {
+	var certs []domain.Certificate
+	if err := r.DB.Select("certificates.*, devices.name AS device_name").
+		Joins("JOIN devices ON devices.id = certificates.device_id").
+		Where("certificates.not_after < ?", t).
+		Order("certificates.not_after ASC").
+		Find(&certs).Error; err != nil {
+		return nil, err
+	}
+	return certs, nil
+}
+
+func (r *CertificateRepository) GetCertificatesByDevice(deviceID uint) ([]domain.Certificate, error) {
+	var certs []domain.Certificate
+	if err := r.DB.Where("device_id = ?", deviceID).Order("not_after ASC").Find(&certs).Error; err != nil {
+		return nil, err
+	}
+	return certs, nil
+}
+
+func (r *CertificateRepository) DeleteCertificatesByDevice(deviceID uint) error {
+	return r.DB.Where("device_id = ?", deviceID).Delete(&domain.Certificate{}).Error
+}
+
+func (r *CertificateRepository) DeleteCertificatesByCheck(checkID uint) error {
+	return r.DB.Where("check_id = ?", checkID).Delete(&domain.Certificate{}).Error
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"gorm.io/gorm"
+)
+
+type CheckRepository struct {
+	DB *gorm.DB
+}
+
+func NewCheckRepository(db *gorm.DB) *CheckRepository {
+	return &CheckRepository{DB: db}
+}
+
+func (r *CheckRepository) CreateCheck(check *domain.Check) error {
+	return r.DB.Create(check).Error
+}
+
+func (r *CheckRepository) UpdateCheck(check *domain.Check) error {
+	return r.DB.Model(&domain.Check{}).Where("id = ?", check.ID).Select("type", "name", "params", "disabled", "token", "updated_by").Updates(check).Error
+}
+
+func (r *CheckRepository) GetCheckByID(id uint) (*domain.Check, error) {
+	var check domain.Check
+	if err := r.DB.First(&check, id).Error; err != nil {
+		return nil, err
+	}
+	return &check, nil
+}
+
+func (r *CheckRepository) GetCheckByToken(token string) (*domain.Check, error) {
+	var check domain.Check
+	if err := r.DB.Where("token = ?", token).First(&check).Error; err != nil {
+		return nil, err
+	}
+	return &check, nil
+}
+
+func (r *CheckRepository) GetChecksByDevice(deviceID uint) ([]domain.Check, error) {
+	var checks []domain.Check
+	if err := r.DB.Where("device_id = ?", deviceID).Order("id ASC").Find(&checks).Error; err != nil {
+		return nil, err
+	}
+	return checks, nil
+}
+
+func (r *CheckRepository) UpdateCheckResult(id uint, fields map[string]interface{}) error {
+	return r.DB.Model(&domain.Check{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *CheckRepository) DeleteCheck(id uint) error {
+	return r.DB.Delete(&domain.Check{}, id).Error
+}
+
+func (r *CheckRepository) DeleteChecksByDevice(deviceID uint) error {
+	return r.DB.Where("device_id = ?", deviceID).Delete(&domain.Check{}).Error
+}
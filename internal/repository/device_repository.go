@@ -45,3 +45,25 @@ func (r *DeviceRepository) GetDeviceByID(id uint) (*domain.Device, error) {
 func (r *DeviceRepository) DeleteDevice(id uint) error {
 	return r.DB.Delete(&domain.Device{}, id).Error
 }
+
+func (r *DeviceRepository) GetDevicesByLocation(locationID uint) ([]domain.Device, error) {
+	var devices []domain.Device
+	if err := r.DB.Where("location_id = ?", locationID).Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// GetChildren returns the devices whose parent is parentID.
+func (r *DeviceRepository) GetChildren(parentID uint) ([]domain.Device, error) {
+	var devices []domain.Device
+	if err := r.DB.Where("parent_id = ?", parentID).Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// ClearParent detaches the children of parentID.
+func (r *DeviceRepository) ClearParent(parentID uint) error {
+	return r.DB.Model(&domain.Device{}).Where("parent_id = ?", parentID).Update("parent_id", nil).Error
+}
@@ -41,3 +41,11 @@ func (r *DeviceTypeMapRepository) GetDeviceTypes(deviceID uint) ([]domain.Device
 	}
 	return types, nil
 }
+
+func (r *DeviceTypeMapRepository) GetAllMaps() ([]domain.DeviceTypeMap, error) {
+	var maps []domain.DeviceTypeMap
+	if err := r.DB.Find(&maps).Error; err != nil {
+		return nil, err
+	}
+	return maps, nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"gorm.io/gorm"
+)
+
+type EscalationRepository struct {
+	DB *gorm.DB
+}
+
+func NewEscalationRepository(db *gorm.DB) *EscalationRepository {
+	return &EscalationRepository{DB: db}
+}
+
+func (r *EscalationRepository) CreatePolicy(p *domain.EscalationPolicy) error {
+	return r.DB.Create(p).Error
+}
+
+func (r *EscalationRepository) UpdatePolicy(p *domain.EscalationPolicy) error {
+	return r.DB.Model(&domain.EscalationPolicy{}).Where("id = ?", p.ID).
+		Select("name", "description", "steps", "repeat", "updated_by").
+		Updates(p).Error
+}
+
+func (r *EscalationRepository) GetAllPolicies() ([]domain.EscalationPolicy, error) {
+	var policies []domain.EscalationPolicy
+	if err := r.DB.Order("id ASC").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (r *EscalationRepository) GetPolicyByID(id uint) (*domain.EscalationPolicy, error) {
+	var p domain.EscalationPolicy
+	if err := r.DB.First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *EscalationRepository) DeletePolicy(id uint) error {
+	return r.DB.Delete(&domain.EscalationPolicy{}, id).Error
+}
+
+func (r *EscalationRepository) CreateSchedule(s *domain.OnCallSchedule) error {
+	return r.DB.Create(s).Error
+}
+
+func (r *EscalationRepository) UpdateSchedule(s *domain.OnCallSchedule) error {
+	return r.DB.Model(&domain.OnCallSchedule{}).Where("id = ?", s.ID).
+		Select("name", "rotation_start", "rotation_hours", "participants", "updated_by").
+		Updates(s).Error
+}
+
+func (r *EscalationRepository) GetAllSchedules() ([]domain.OnCallSchedule, error) {
+	var schedules []domain.OnCallSchedule
+	if err := r.DB.Order("id ASC").Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (r *EscalationRepository) GetScheduleByID(id uint) (*domain.OnCallSchedule, error) {
+	var s domain.OnCallSchedule
+	if err := r.DB.First(&s, id).Error; err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// DeleteSchedule deletes a schedule with its overrides.
+func (r *EscalationRepository) DeleteSchedule(id uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("schedule_id = ?", id).Delete(&domain.OnCallOverride{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.OnCallSchedule{}, id).Error
+	})
+}
+
+func (r *EscalationRepository) CreateOverride(o *domain.OnCallOverride) error {
+	return r.DB.Create(o).Error
+}
+
+// GetOverrides returns the overrides of a schedule that end after
+// endsAfter, in the order they start.
+func (r *EscalationRepository) GetOverrides(scheduleID uint, endsAfter time.Time) ([]domain.OnCallOverride, error) {
+	var overrides []domain.OnCallOverride
+	if err := r.DB.Where("schedule_id = ? AND ends_at > ?", scheduleID, endsAfter).
+		Order("starts_at ASC").Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// GetOverridesByChannel returns the overrides sending to a channel that
+// end after endsAfter.
+func (r *EscalationRepository) GetOverridesByChannel(channelID uint, endsAfter time.Time) ([]domain.OnCallOverride, error) {
+	var overrides []domain.OnCallOverride
+	if err := r.DB.Where("channel_id = ? AND ends_at > ?", channelID, endsAfter).
+		Order("starts_at ASC").Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+func (r *EscalationRepository) DeleteOverride(id uint) error {
+	return r.DB.Delete(&domain.OnCallOverride{}, id).Error
+}
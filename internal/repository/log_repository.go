@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"gorm.io/gorm"
+)
+
+type LogRepository struct {
+	DB *gorm.DB
+}
+
+func NewLogRepository(db *gorm.DB) *LogRepository {
+	return &LogRepository{DB: db}
+}
+
+// LogFilter selects status transitions. Zero values are not applied.
+type LogFilter struct {
+	DeviceID   uint
+	LocationID uint
+	Statuses   []string
+	From       time.Time
+	To         time.Time
+	Ascending  bool
+	Page       int
+	PageSize   int
+}
+
+// GetLogs returns one page of transitions matching f, with device and
+// location names, and the total number of matching transitions.
+func (r *LogRepository) GetLogs(f LogFilter) ([]domain.Log, int64, error) {
+	q := r.DB.Model(&domain.Log{}).
+		Joins("LEFT JOIN devices ON devices.id = logss.device_id").
+		Joins("LEFT JOIN locations ON locations.id = devices.location_id")
+	if f.DeviceID != 0 {
+		q = q.Where("logss.device_id = ?", f.DeviceID)
+	}
+	if f.LocationID != 0 {
+		q = q.Where("devices.location_id = ?", f.LocationID)
+	}
+	if len(f.Statuses) > 0 {
+		q = q.Where("logss.newstatus IN ?", f.Statuses)
+	}
+	if !f.From.IsZero() {
+		q = q.Where("logss.log_time >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		q = q.Where("logss.log_time < ?", f.To)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := "logss.log_time DESC, logss.id DESC"
+	if f.Ascending {
+		order = "logss.log_time ASC, logss.id ASC"
+	}
+	var logs []domain.Log
+	if err := q.Select("logss.*, devices.name AS device_name, devices.location_id AS location_id, locations.name AS location_name").
+		Order(order).
+		Offset((f.Page - 1) * f.PageSize).
+		Limit(f.PageSize).
+		Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+// GetTransitions returns the transitions in [from, to) ordered by device
+// and time.
+func (r *LogRepository) GetTransitions(from, to time.Time) ([]domain.Log, error) {
+	var logs []domain.Log
+	err := r.DB.Where("log_time >= ? AND log_time < ?", from, to).
+		Order("device_id, log_time, id").
+		Find(&logs).Error
+	return logs, err
+}
+
+// GetStatusesAt returns the status each device had at t according to its
+// last transition before t. Devices without such a transition are missing.
+func (r *LogRepository) GetStatusesAt(t time.Time) (map[uint]string, error) {
+	var rows []struct {
+		DeviceID  uint
+		NewStatus string
+	}
+	err := r.DB.Raw(`SELECT DISTINCT ON (device_id) device_id, newstatus AS new_status
+		FROM logss WHERE log_time < ? ORDER BY device_id, log_time DESC, id DESC`, t).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	statuses := make(map[uint]string, len(rows))
+	for _, row := range rows {
+		statuses[row.DeviceID] = row.NewStatus
+	}
+	return statuses, nil
+}
+
+// GetLastTransition returns the most recent transition of a device, or nil
+// when it has none.
+func (r *LogRepository) GetLastTransition(deviceID uint) (*domain.Log, error) {
+	var logs []domain.Log
+	if err := r.DB.Where("device_id = ?", deviceID).Order("log_time DESC, id DESC").Limit(1).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	if len(logs) == 0 {
+		return nil, nil
+	}
+	return &logs[0], nil
+}
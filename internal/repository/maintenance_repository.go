@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"gorm.io/gorm"
+)
+
+type MaintenanceRepository struct {
+	DB *gorm.DB
+}
+
+func NewMaintenanceRepository(db *gorm.DB) *MaintenanceRepository {
+	return &MaintenanceRepository{DB: db}
+}
+
+func (r *MaintenanceRepository) CreateWindow(w *domain.MaintenanceWindow) error {
+	return r.DB.Create(w).Error
+}
+
+func (r *MaintenanceRepository) UpdateWindow(w *domain.MaintenanceWindow) error {
+	return r.DB.Model(&domain.MaintenanceWindow{}).Where("id = ?", w.ID).
+		Select("name", "description", "device_ids", "type_ids", "location_ids", "starts_at", "ends_at", "schedule", "duration_minutes", "disabled", "updated_by").
+		Updates(w).Error
+}
+
+func (r *MaintenanceRepository) GetAllWindows() ([]domain.MaintenanceWindow, error) {
+	var windows []domain.MaintenanceWindow
+	if err := r.DB.Order("id ASC").Find(&windows).Error; err != nil {
+		return nil, err
+	}
+	return windows, nil
+}
+
+func (r *MaintenanceRepository) GetWindowByID(id uint) (*domain.MaintenanceWindow, error) {
+	var w domain.MaintenanceWindow
+	if err := r.DB.First(&w, id).Error; err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (r *MaintenanceRepository) DeleteWindow(id uint) error {
+	return r.DB.Delete(&domain.MaintenanceWindow{}, id).Error
+}
+
+func (r *MaintenanceRepository) CreateSilence(s *domain.Silence) error {
+	return r.DB.Create(s).Error
+}
+
+// GetSilences returns silences, newest first. With expiresAfter set only
+// the silences that have not expired by then are returned, including ones
+// that have yet to start.
+func (r *MaintenanceRepository) GetSilences(expiresAfter *time.Time) ([]domain.Silence, error) {
+	q := r.DB.Order("id DESC")
+	if expiresAfter != nil {
+		q = q.Where("expires_at > ?", *expiresAfter)
+	}
+	var silences []domain.Silence
+	if err := q.Find(&silences).Error; err != nil {
+		return nil, err
+	}
+	return silences, nil
+}
+
+func (r *MaintenanceRepository) GetSilenceByID(id uint) (*domain.Silence, error) {
+	var s domain.Silence
+	if err := r.DB.First(&s, id).Error; err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *MaintenanceRepository) ExpireSilence(id uint, at time.Time) error {
+	return r.DB.Model(&domain.Silence{}).Where("id = ?", id).Update("expires_at", at).Error
+}
+
+func (r *MaintenanceRepository) DeleteSilence(id uint) error {
+	return r.DB.Delete(&domain.Silence{}, id).Error
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"gorm.io/gorm"
+)
+
+type MetricRepository struct {
+	DB *gorm.DB
+}
+
+func NewMetricRepository(db *gorm.DB) *MetricRepository {
+	return &MetricRepository{DB: db}
+}
+
+func (r *MetricRepository) InsertMetrics(metrics []domain.DeviceMetric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	return r.DB.Create(&metrics).Error
+}
+
+// MetricFilter narrows an aggregation to one check or check type.
+type MetricFilter struct {
+	CheckID   uint
+	CheckType string
+}
+
+// GetAggregatedMetrics groups the metrics of a device between from and to
+// into buckets of step, aligned to the Unix epoch.
+func (r *MetricRepository) GetAggregatedMetrics(deviceID uint, from, to time.Time, step time.Duration, filter MetricFilter) ([]domain.MetricPoint, error) {
+	seconds := step.Seconds()
+	q := r.DB.Model(&domain.DeviceMetric{}).
+		Select(`to_timestamp(floor(extract(epoch from measured_at) / ?) * ?) AS time,
+			avg(rtt_ms) AS rtt_avg_ms, min(rtt_ms) AS rtt_min_ms, max(rtt_ms) AS rtt_max_ms,
+			avg(loss_pct) AS loss_avg_pct, avg(duration_ms) AS duration_avg_ms,
+			count(*) AS samples, count(*) FILTER (WHERE status = ?) AS failures`,
+			seconds, seconds, domain.StatusOffline).
+		Where("device_id = ? AND measured_at >= ? AND measured_at < ?", deviceID, from, to)
+	if filter.CheckID != 0 {
+		q = q.Where("check_id = ?", filter.CheckID)
+	}
+	if filter.CheckType != "" {
+		q = q.Where("check_type = ?", filter.CheckType)
+	}
+	var points []domain.MetricPoint
+	if err := q.Group("1").Order("1").Scan(&points).Error; err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+func (r *MetricRepository) DeleteMetricsBefore(t time.Time) (int64, error) {
+	res := r.DB.Where("measured_at < ?", t).Delete(&domain.DeviceMetric{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *MetricRepository) DeleteMetricsByDevice(deviceID uint) error {
+	return r.DB.Where("device_id = ?", deviceID).Delete(&domain.DeviceMetric{}).Error
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository struct {
+	DB *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{DB: db}
+}
+
+func (r *NotificationRepository) CreateChannel(ch *domain.NotificationChannel) error {
+	return r.DB.Create(ch).Error
+}
+
+func (r *NotificationRepository) UpdateChannel(ch *domain.NotificationChannel) error {
+	return r.DB.Model(&domain.NotificationChannel{}).Where("id = ?", ch.ID).
+		Select("name", "type", "params", "subject_template", "body_template", "min_severity", "events", "disabled", "updated_by").
+		Updates(ch).Error
+}
+
+func (r *NotificationRepository) GetAllChannels() ([]domain.NotificationChannel, error) {
+	var channels []domain.NotificationChannel
+	if err := r.DB.Order("id ASC").Find(&channels).Error; err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+func (r *NotificationRepository) GetChannelByID(id uint) (*domain.NotificationChannel, error) {
+	var ch domain.NotificationChannel
+	if err := r.DB.First(&ch, id).Error; err != nil {
+		return nil, err
+	}
+	return &ch, nil
+}
+
+func (r *NotificationRepository) DeleteChannel(id uint) error {
+	return r.DB.Delete(&domain.NotificationChannel{}, id).Error
+}
+
+func (r *NotificationRepository) CreateDelivery(d *domain.NotificationDelivery) error {
+	return r.DB.Create(d).Error
+}
+
+// GetDeliveries returns the newest deliveries, optionally only those of
+// one alert or channel.
+func (r *NotificationRepository) GetDeliveries(alertID, channelID uint, limit int) ([]domain.NotificationDelivery, error) {
+	q := r.DB.Order("id DESC").Limit(limit)
+	if alertID != 0 {
+		q = q.Where("alert_id = ?", alertID)
+	}
+	if channelID != 0 {
+		q = q.Where("channel_id = ?", channelID)
+	}
+	var deliveries []domain.NotificationDelivery
+	if err := q.Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
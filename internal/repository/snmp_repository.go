@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SNMPRepository struct {
+	DB *gorm.DB
+}
+
+func NewSNMPRepository(db *gorm.DB) *SNMPRepository {
+	return &SNMPRepository{DB: db}
+}
+
+func (r *SNMPRepository) GetCredential(deviceID uint) (*domain.SNMPCredential, error) {
+	var cred domain.SNMPCredential
+	if err := r.DB.Where("device_id = ?", deviceID).First(&cred).Error; err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
+
+// SaveCredential creates or replaces the credential of a device.
+func (r *SNMPRepository) SaveCredential(cred *domain.SNMPCredential) error {
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "device_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"version", "port", "community", "username", "auth_protocol", "auth_passphrase", "priv_protocol", "priv_passphrase", "context_name", "disabled", "updated_at", "updated_by"}),
+	}).Create(cred).Error
+}
+
+func (r *SNMPRepository) DeleteCredential(deviceID uint) error {
+	return r.DB.Where("device_id = ?", deviceID).Delete(&domain.SNMPCredential{}).Error
+}
+
+// GetPolledDevices returns devices that have an enabled SNMP credential.
+func (r *SNMPRepository) GetPolledDevices() ([]domain.Device, error) {
+	var devices []domain.Device
+	if err := r.DB.Joins("JOIN snmp_credentials ON snmp_credentials.device_id = devices.id").
+		Where("snmp_credentials.disabled = ?", false).
+		Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+func (r *SNMPRepository) CreateOIDSet(set *domain.OIDSet) error {
+	return r.DB.Create(set).Error
+}
+
+func (r *SNMPRepository) UpdateOIDSet(set *domain.OIDSet) error {
+	return r.DB.Model(&domain.OIDSet{}).Where("id = ?", set.ID).Select("name", "description", "oids").Updates(set).Error
+}
+
+func (r *SNMPRepository) GetAllOIDSets() ([]domain.OIDSet, error) {
+	var sets []domain.OIDSet
+	if err := r.DB.Order("name ASC").Find(&sets).Error; err != nil {
+		return nil, err
+	}
+	return sets, nil
+}
+
+func (r *SNMPRepository) GetOIDSetByID(id uint) (*domain.OIDSet, error) {
+	var set domain.OIDSet
+	if err := r.DB.First(&set, id).Error; err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+// EnsureOIDSet creates set unless a set with the same name exists.
+func (r *SNMPRepository) EnsureOIDSet(set *domain.OIDSet) error {
+	return r.DB.Where(domain.OIDSet{Name: set.Name}).FirstOrCreate(set).Error
+}
+
+func (r *SNMPRepository) DeleteOIDSet(id uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("oid_set_id = ?", id).Delete(&domain.DeviceTypeOIDSet{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.OIDSet{}, id).Error
+	})
+}
+
+func (r *SNMPRepository) GetOIDSetsByTypes(typeIDs []uint) ([]domain.OIDSet, error) {
+	var sets []domain.OIDSet
+	if len(typeIDs) == 0 {
+		return sets, nil
+	}
+	if err := r.DB.Distinct("snmp_oid_sets.*").
+		Joins("JOIN device_type_oid_sets ON device_type_oid_sets.oid_set_id = snmp_oid_sets.id").
+		Where("device_type_oid_sets.type_id IN ?", typeIDs).
+		Find(&sets).Error; err != nil {
+		return nil, err
+	}
+	return sets, nil
+}
+
+// SetTypeOIDSets replaces the OID sets attached to a device type.
+func (r *SNMPRepository) SetTypeOIDSets(typeID uint, setIDs []uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("type_id = ?", typeID).Delete(&domain.DeviceTypeOIDSet{}).Error; err != nil {
+			return err
+		}
+		if len(setIDs) == 0 {
+			return nil
+		}
+		maps := make([]domain.DeviceTypeOIDSet, 0, len(setIDs))
+		for _, id := range setIDs {
+			maps = append(maps, domain.DeviceTypeOIDSet{TypeID: typeID, OIDSetID: id})
+		}
+		return tx.Create(&maps).Error
+	})
+}
+
+func (r *SNMPRepository) InsertMetrics(metrics []domain.SNMPMetric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	return r.DB.CreateInBatches(metrics, 500).Error
+}
+
+// GetMetrics returns the polled values of a device between from and to,
+// optionally limited to one metric name.
+func (r *SNMPRepository) GetMetrics(deviceID uint, name string, from, to time.Time) ([]domain.SNMPMetric, error) {
+	var metrics []domain.SNMPMetric
+	q := r.DB.Where("device_id = ? AND polled_at BETWEEN ? AND ?", deviceID, from, to)
+	if name != "" {
+		q = q.Where("name = ?", name)
+	}
+	if err := q.Order("polled_at ASC, name ASC, instance ASC").Find(&metrics).Error; err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+func (r *SNMPRepository) DeleteMetricsBefore(t time.Time) (int64, error) {
+	res := r.DB.Where("polled_at < ?", t).Delete(&domain.SNMPMetric{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *SNMPRepository) DeleteMetricsByDevice(deviceID uint) error {
+	return r.DB.Where("device_id = ?", deviceID).Delete(&domain.SNMPMetric{}).Error
+}
@@ -0,0 +1,291 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+)
+
+// ListFunc returns the devices that should be scheduled.
+type ListFunc func() ([]domain.Device, error)
+
+// CheckFunc checks a single device. It must return once ctx is done.
+type CheckFunc func(ctx context.Context, device domain.Device)
+
+type Config struct {
+	Name         string        // used in log lines
+	Workers      int           // size of the worker pool
+	Interval     time.Duration // how often each device is checked
+	CycleTimeout time.Duration // deadline for a single cycle
+	Tick         time.Duration // how often due devices are collected
+}
+
+// ConfigFromEnv reads PROBE_WORKERS, PROBE_INTERVAL, PROBE_CYCLE_TIMEOUT
+// and PROBE_TICK, falling back to defaults for missing or invalid values.
+func ConfigFromEnv() Config {
+	return LoadConfig("PROBE", Config{
+		Name:         "Probe",
+		Workers:      50,
+		Interval:     5 * time.Second,
+		CycleTimeout: 10 * time.Second,
+		Tick:         time.Second,
+	})
+}
+
+// LoadConfig reads <prefix>_WORKERS, <prefix>_INTERVAL,
+// <prefix>_CYCLE_TIMEOUT and <prefix>_TICK, keeping the values of def for
+// missing or invalid ones.
+func LoadConfig(prefix string, def Config) Config {
+	return Config{
+		Name:         def.Name,
+		Workers:      envInt(prefix+"_WORKERS", def.Workers),
+		Interval:     envDuration(prefix+"_INTERVAL", def.Interval),
+		CycleTimeout: envDuration(prefix+"_CYCLE_TIMEOUT", def.CycleTimeout),
+		Tick:         envDuration(prefix+"_TICK", def.Tick),
+	}
+}
+
+type Stats struct {
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"-"`
+	// DurationMs is Duration in milliseconds, as durations are reported
+	// elsewhere in the API.
+	DurationMs float64 `json:"duration_ms"`
+	Devices    int     `json:"devices"`
+	Due        int     `json:"due"`
+	Checked    int     `json:"checked"`
+	Skipped    int     `json:"skipped"`
+}
+
+type Scheduler struct {
+	cfg   Config
+	list  ListFunc
+	check CheckFunc
+
+	mu      sync.Mutex
+	nextRun map[uint]time.Time
+	running map[uint]bool
+	last    Stats
+}
+
+func New(cfg Config, list ListFunc, check CheckFunc) *Scheduler {
+	if cfg.Name == "" {
+		cfg.Name = "Scheduler"
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	if cfg.CycleTimeout <= 0 {
+		cfg.CycleTimeout = cfg.Interval
+	}
+	if cfg.Tick <= 0 {
+		cfg.Tick = time.Second
+	}
+	return &Scheduler{
+		cfg:     cfg,
+		list:    list,
+		check:   check,
+		nextRun: make(map[uint]time.Time),
+		running: make(map[uint]bool),
+	}
+}
+
+// Run executes cycles every Tick until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Tick)
+	defer ticker.Stop()
+	for {
+		stats := s.RunCycle(ctx)
+		if stats.Due > 0 {
+			log.Printf("%s cycle: %d devices, %d due, %d checked, %d skipped in %s",
+				s.cfg.Name, stats.Devices, stats.Due, stats.Checked, stats.Skipped, stats.Duration)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunCycle checks every device that is due, using at most Workers
+// concurrent checks. Devices that could not be started before the cycle
+// deadline, or whose previous check is still running, are skipped and
+// stay due for the next cycle.
+func (s *Scheduler) RunCycle(ctx context.Context) Stats {
+	start := time.Now()
+	stats := Stats{StartedAt: start}
+
+	devices, err := s.list()
+	if err != nil {
+		log.Printf("Error fetching devices: %v", err)
+		return stats
+	}
+	stats.Devices = len(devices)
+
+	due, busy := s.collectDue(devices, start)
+	stats.Due = len(due) + busy
+	stats.Skipped = busy
+	if len(due) == 0 {
+		stats.Duration = time.Since(start)
+		s.setLast(stats)
+		return stats
+	}
+
+	cycleCtx, cancel := context.WithTimeout(ctx, s.cfg.CycleTimeout)
+	defer cancel()
+
+	jobs := make(chan domain.Device)
+	var checked atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < min(s.cfg.Workers, len(due)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for device := range jobs {
+				checkStart := time.Now()
+				s.check(cycleCtx, device)
+				checked.Add(1)
+				s.finish(device.ID, checkStart)
+			}
+		}()
+	}
+
+dispatch:
+	for i, device := range due {
+		select {
+		case jobs <- device:
+		case <-cycleCtx.Done():
+			for _, d := range due[i:] {
+				s.release(d.ID)
+			}
+			stats.Skipped += len(due) - i
+			break dispatch
+		}
+	}
+	close(jobs)
+
+	// Do not let a stuck check hold the cycle past its deadline; it stays
+	// marked as running and is skipped until it returns.
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-cycleCtx.Done():
+	}
+
+	stats.Checked = int(checked.Load())
+	stats.Duration = time.Since(start)
+	s.setLast(stats)
+	return stats
+}
+
+// LastStats returns the stats of the most recent cycle.
+func (s *Scheduler) LastStats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// TryStart marks device id as being checked outside a cycle, so cycles
+// skip it until Done is called. It reports false when a check of the
+// device is already running.
+func (s *Scheduler) TryStart(id uint) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running[id] {
+		return false
+	}
+	s.running[id] = true
+	return true
+}
+
+// Done ends a check started with TryStart. The device is next due one
+// interval later.
+func (s *Scheduler) Done(id uint) {
+	s.finish(id, time.Now())
+}
+
+func (s *Scheduler) collectDue(devices []domain.Device, now time.Time) ([]domain.Device, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[uint]bool, len(devices))
+	var due []domain.Device
+	busy := 0
+	for _, device := range devices {
+		seen[device.ID] = true
+		next, ok := s.nextRun[device.ID]
+		if !ok {
+			// Spread new devices over one interval so they do not all
+			// become due on the same tick.
+			next = now.Add(s.offset(device.ID))
+			s.nextRun[device.ID] = next
+		}
+		if now.Before(next) {
+			continue
+		}
+		if s.running[device.ID] {
+			busy++
+			continue
+		}
+		s.running[device.ID] = true
+		due = append(due, device)
+	}
+	for id := range s.nextRun {
+		if !seen[id] && !s.running[id] {
+			delete(s.nextRun, id)
+		}
+	}
+	return due, busy
+}
+
+func (s *Scheduler) offset(id uint) time.Duration {
+	return time.Duration(uint64(id) * 2654435761 % uint64(s.cfg.Interval))
+}
+
+func (s *Scheduler) finish(id uint, startedAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, id)
+	s.nextRun[id] = startedAt.Add(s.cfg.Interval)
+}
+
+func (s *Scheduler) release(id uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, id)
+}
+
+func (s *Scheduler) setLast(stats Stats) {
+	stats.DurationMs = float64(stats.Duration) / float64(time.Millisecond)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last = stats
+}
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	if v, err := time.ParseDuration(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,244 @@
+package snmp
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+)
+
+// BaseOIDs are polled on every device with SNMP credentials.
+var BaseOIDs = []domain.SNMPOID{
+	{Name: "sysUpTime", OID: ".1.3.6.1.2.1.1.3.0"},
+}
+
+// BuiltinOIDSets are created on startup so device types can be linked to
+// common MIBs without typing OIDs.
+var BuiltinOIDSets = []domain.OIDSet{
+	{
+		Name:        "IF-MIB",
+		Description: "Interface status, traffic and error counters",
+		OIDs: domain.SNMPOIDList{
+			{Name: "ifOperStatus", OID: ".1.3.6.1.2.1.2.2.1.8", Walk: true},
+			{Name: "ifHCInOctets", OID: ".1.3.6.1.2.1.31.1.1.1.6", Walk: true},
+			{Name: "ifHCOutOctets", OID: ".1.3.6.1.2.1.31.1.1.1.10", Walk: true},
+			{Name: "ifInErrors", OID: ".1.3.6.1.2.1.2.2.1.14", Walk: true},
+			{Name: "ifOutErrors", OID: ".1.3.6.1.2.1.2.2.1.20", Walk: true},
+		},
+	},
+	{
+		Name:        "HOST-RESOURCES-MIB",
+		Description: "Per-CPU load and storage usage",
+		OIDs: domain.SNMPOIDList{
+			{Name: "hrProcessorLoad", OID: ".1.3.6.1.2.1.25.3.3.1.2", Walk: true},
+			{Name: "hrStorageSize", OID: ".1.3.6.1.2.1.25.2.3.1.5", Walk: true},
+			{Name: "hrStorageUsed", OID: ".1.3.6.1.2.1.25.2.3.1.6", Walk: true},
+		},
+	},
+	{
+		Name:        "UCD-SNMP-MIB",
+		Description: "CPU idle and memory of net-snmp based devices",
+		OIDs: domain.SNMPOIDList{
+			{Name: "ssCpuIdle", OID: ".1.3.6.1.4.1.2021.11.11.0"},
+			{Name: "memTotalReal", OID: ".1.3.6.1.4.1.2021.4.5.0"},
+			{Name: "memAvailReal", OID: ".1.3.6.1.4.1.2021.4.6.0"},
+		},
+	},
+}
+
+type Value struct {
+	Name     string
+	OID      string
+	Instance string
+	Value    float64
+	Text     string
+}
+
+// Poller reads OIDs from SNMP agents. NewHandler can be replaced to poll
+// through a stand-in agent or a mock.
+type Poller struct {
+	Timeout    time.Duration
+	Retries    int
+	NewHandler func() gosnmp.Handler
+}
+
+func NewPoller() *Poller {
+	return &Poller{
+		Timeout:    2 * time.Second,
+		Retries:    1,
+		NewHandler: gosnmp.NewHandler,
+	}
+}
+
+// Poll connects to host with cred and reads oids, walking the ones marked
+// Walk. Scalars that the agent does not know are skipped.
+func (p *Poller) Poll(host string, cred domain.SNMPCredential, oids []domain.SNMPOID) ([]Value, error) {
+	h, err := p.handler(host, cred)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.Connect(); err != nil {
+		return nil, fmt.Errorf("snmp connect: %w", err)
+	}
+	defer h.Close()
+
+	var values []Value
+	var scalars []domain.SNMPOID
+	for _, o := range oids {
+		o.OID = "." + strings.TrimPrefix(o.OID, ".")
+		if !o.Walk {
+			scalars = append(scalars, o)
+			continue
+		}
+		pdus, err := h.BulkWalkAll(o.OID)
+		if err != nil {
+			return nil, fmt.Errorf("snmp walk %s: %w", o.Name, err)
+		}
+		for _, pdu := range pdus {
+			values = append(values, toValue(o, pdu))
+		}
+	}
+
+	for start := 0; start < len(scalars); start += gosnmp.MaxOids {
+		batch := scalars[start:min(start+gosnmp.MaxOids, len(scalars))]
+		names := make([]string, len(batch))
+		for i, o := range batch {
+			names[i] = o.OID
+		}
+		packet, err := h.Get(names)
+		if err != nil {
+			return nil, fmt.Errorf("snmp get: %w", err)
+		}
+		for i, pdu := range packet.Variables {
+			if i >= len(batch) || pdu.Type == gosnmp.NoSuchObject || pdu.Type == gosnmp.NoSuchInstance || pdu.Type == gosnmp.Null {
+				continue
+			}
+			values = append(values, toValue(batch[i], pdu))
+		}
+	}
+	return values, nil
+}
+
+func (p *Poller) handler(host string, cred domain.SNMPCredential) (gosnmp.Handler, error) {
+	h := p.NewHandler()
+	h.SetTarget(host)
+	h.SetPort(161)
+	if cred.Port > 0 {
+		h.SetPort(uint16(cred.Port))
+	}
+	h.SetTimeout(p.Timeout)
+	h.SetRetries(p.Retries)
+
+	switch cred.Version {
+	case domain.SNMPVersion2c, "":
+		h.SetVersion(gosnmp.Version2c)
+		h.SetCommunity(cred.Community)
+	case domain.SNMPVersion3:
+		params, flags, err := usmParameters(cred)
+		if err != nil {
+			return nil, err
+		}
+		h.SetVersion(gosnmp.Version3)
+		h.SetSecurityModel(gosnmp.UserSecurityModel)
+		h.SetMsgFlags(flags)
+		h.SetSecurityParameters(params)
+		h.SetContextName(cred.ContextName)
+	default:
+		return nil, fmt.Errorf("unsupported snmp version %q", cred.Version)
+	}
+	return h, nil
+}
+
+func usmParameters(cred domain.SNMPCredential) (*gosnmp.UsmSecurityParameters, gosnmp.SnmpV3MsgFlags, error) {
+	params := &gosnmp.UsmSecurityParameters{
+		UserName:               cred.Username,
+		AuthenticationProtocol: gosnmp.NoAuth,
+		PrivacyProtocol:        gosnmp.NoPriv,
+	}
+	flags := gosnmp.NoAuthNoPriv
+	if cred.AuthProtocol != "" {
+		auth, ok := authProtocols[strings.ToUpper(cred.AuthProtocol)]
+		if !ok {
+			return nil, 0, fmt.Errorf("unsupported auth protocol %q", cred.AuthProtocol)
+		}
+		params.AuthenticationProtocol = auth
+		params.AuthenticationPassphrase = cred.AuthPassphrase
+		flags = gosnmp.AuthNoPriv
+	}
+	if cred.PrivProtocol != "" {
+		if flags == gosnmp.NoAuthNoPriv {
+			return nil, 0, fmt.Errorf("privacy requires an auth protocol")
+		}
+		priv, ok := privProtocols[strings.ToUpper(cred.PrivProtocol)]
+		if !ok {
+			return nil, 0, fmt.Errorf("unsupported privacy protocol %q", cred.PrivProtocol)
+		}
+		params.PrivacyProtocol = priv
+		params.PrivacyPassphrase = cred.PrivPassphrase
+		flags = gosnmp.AuthPriv
+	}
+	return params, flags, nil
+}
+
+var authProtocols = map[string]gosnmp.SnmpV3AuthProtocol{
+	"MD5":    gosnmp.MD5,
+	"SHA":    gosnmp.SHA,
+	"SHA224": gosnmp.SHA224,
+	"SHA256": gosnmp.SHA256,
+	"SHA384": gosnmp.SHA384,
+	"SHA512": gosnmp.SHA512,
+}
+
+var privProtocols = map[string]gosnmp.SnmpV3PrivProtocol{
+	"DES":     gosnmp.DES,
+	"AES":     gosnmp.AES,
+	"AES192":  gosnmp.AES192,
+	"AES256":  gosnmp.AES256,
+	"AES192C": gosnmp.AES192C,
+	"AES256C": gosnmp.AES256C,
+}
+
+// ValidateCredential reports settings that would make polling fail.
+func ValidateCredential(cred domain.SNMPCredential) error {
+	switch cred.Version {
+	case domain.SNMPVersion2c:
+		if cred.Community == "" {
+			return fmt.Errorf("community is required for SNMP v2c")
+		}
+	case domain.SNMPVersion3:
+		if cred.Username == "" {
+			return fmt.Errorf("username is required for SNMP v3")
+		}
+		_, _, err := usmParameters(cred)
+		return err
+	default:
+		return fmt.Errorf("unsupported snmp version %q", cred.Version)
+	}
+	return nil
+}
+
+// toValue converts a PDU to a metric value. TimeTicks are converted from
+// hundredths of a second to seconds.
+func toValue(o domain.SNMPOID, pdu gosnmp.SnmpPDU) Value {
+	v := Value{Name: o.Name, OID: pdu.Name}
+	if o.Walk {
+		v.Instance = strings.TrimPrefix(strings.TrimPrefix(pdu.Name, o.OID), ".")
+	}
+	switch pdu.Type {
+	case gosnmp.OctetString:
+		if b, ok := pdu.Value.([]byte); ok {
+			v.Text = string(b)
+		}
+	case gosnmp.TimeTicks:
+		f, _ := new(big.Float).SetInt(gosnmp.ToBigInt(pdu.Value)).Float64()
+		v.Value = f / 100
+	case gosnmp.Integer, gosnmp.Counter32, gosnmp.Gauge32, gosnmp.Counter64, gosnmp.Uinteger32:
+		v.Value, _ = new(big.Float).SetInt(gosnmp.ToBigInt(pdu.Value)).Float64()
+	default:
+		v.Text = fmt.Sprint(pdu.Value)
+	}
+	return v
+}
@@ -0,0 +1,326 @@
+package snmp
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+)
+
+const (
+	testEngineID = "\x80\x00\x1f\x88\x80netmon-test"
+	testUser     = "netmon"
+	testAuthPass = "authpassphrase"
+	testPrivPass = "privpassphrase"
+)
+
+// testMIB is served by the stand-in agent.
+var testMIB = []gosnmp.SnmpPDU{
+	{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(123456)},
+	{Name: ".1.3.6.1.2.1.2.2.1.8.1", Type: gosnmp.Integer, Value: 1},
+	{Name: ".1.3.6.1.2.1.2.2.1.8.2", Type: gosnmp.Integer, Value: 2},
+	{Name: ".1.3.6.1.2.1.2.2.1.14.1", Type: gosnmp.Counter32, Value: uint32(7)},
+	{Name: ".1.3.6.1.2.1.25.2.3.1.6.1", Type: gosnmp.Gauge32, Value: uint(4096)},
+	{Name: ".1.3.6.1.2.1.31.1.1.1.6.1", Type: gosnmp.Counter64, Value: uint64(1) << 40},
+	{Name: ".1.3.6.1.2.1.31.1.1.1.6.2", Type: gosnmp.Counter64, Value: uint64(42)},
+	{Name: ".1.3.6.1.4.1.2021.4.5.0", Type: gosnmp.OctetString, Value: []byte("16384 kB")},
+}
+
+// agent is a minimal SNMP agent answering GET and GETBULK requests from
+// testMIB over UDP on localhost. With usm set it speaks SNMPv3 and
+// answers engine discovery; otherwise it speaks v2c with community.
+type agent struct {
+	conn      *net.UDPConn
+	community string
+	usm       *gosnmp.UsmSecurityParameters
+	flags     gosnmp.SnmpV3MsgFlags
+}
+
+func startAgent(t *testing.T, a *agent) int {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	a.conn = conn
+	go a.serve(t)
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func (a *agent) decoder() *gosnmp.GoSNMP {
+	if a.usm == nil {
+		return &gosnmp.GoSNMP{Version: gosnmp.Version2c, Community: a.community}
+	}
+	usm := a.usm.Copy()
+	usm.InitSecurityKeys()
+	return &gosnmp.GoSNMP{
+		Version:            gosnmp.Version3,
+		SecurityModel:      gosnmp.UserSecurityModel,
+		MsgFlags:           a.flags,
+		SecurityParameters: usm,
+	}
+}
+
+func (a *agent) serve(t *testing.T) {
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := a.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		req, err := a.decoder().SnmpDecodePacket(append([]byte(nil), buf[:n]...))
+		if err != nil {
+			t.Logf("agent: decoding request: %v", err)
+			continue
+		}
+		if req.Version != gosnmp.Version3 && req.Community != a.community {
+			continue
+		}
+		out, err := a.respond(req)
+		if err != nil {
+			t.Logf("agent: encoding response: %v", err)
+			continue
+		}
+		a.conn.WriteToUDP(out, addr)
+	}
+}
+
+func (a *agent) respond(req *gosnmp.SnmpPacket) ([]byte, error) {
+	resp := &gosnmp.SnmpPacket{
+		Version:   req.Version,
+		Community: req.Community,
+		PDUType:   gosnmp.GetResponse,
+		RequestID: req.RequestID,
+	}
+	switch req.PDUType {
+	case gosnmp.GetRequest:
+		for _, v := range req.Variables {
+			resp.Variables = append(resp.Variables, get(v.Name))
+		}
+	case gosnmp.GetBulkRequest:
+		for _, v := range req.Variables {
+			resp.Variables = append(resp.Variables, next(v.Name, int(req.MaxRepetitions))...)
+		}
+	}
+	if req.Version != gosnmp.Version3 {
+		return resp.MarshalMsg()
+	}
+
+	usm := a.usm.Copy().(*gosnmp.UsmSecurityParameters)
+	usm.AuthoritativeEngineBoots = 1
+	usm.AuthoritativeEngineTime = uint32(time.Now().Unix() % 100000)
+	if err := usm.InitSecurityKeys(); err != nil {
+		return nil, err
+	}
+	resp.MsgID = req.MsgID
+	resp.MsgFlags = a.flags
+	resp.SecurityModel = gosnmp.UserSecurityModel
+	resp.SecurityParameters = usm
+	resp.ContextEngineID = testEngineID
+	resp.ContextName = req.ContextName
+	if reqUSM := req.SecurityParameters.(*gosnmp.UsmSecurityParameters); reqUSM.AuthoritativeEngineID == "" {
+		// Engine discovery: report the engine ID without authenticating.
+		resp.MsgFlags = gosnmp.NoAuthNoPriv
+		resp.PDUType = gosnmp.Report
+		resp.SecurityParameters = &gosnmp.UsmSecurityParameters{
+			AuthoritativeEngineID:    testEngineID,
+			AuthoritativeEngineBoots: usm.AuthoritativeEngineBoots,
+			AuthoritativeEngineTime:  usm.AuthoritativeEngineTime,
+		}
+		resp.Variables = []gosnmp.SnmpPDU{{Name: ".1.3.6.1.6.3.15.1.1.4.0", Type: gosnmp.Counter32, Value: uint32(1)}}
+		return resp.MarshalMsg()
+	}
+	if err := usm.InitPacket(resp); err != nil {
+		return nil, err
+	}
+	return resp.MarshalMsg()
+}
+
+func get(oid string) gosnmp.SnmpPDU {
+	for _, pdu := range testMIB {
+		if pdu.Name == oid {
+			return pdu
+		}
+	}
+	return gosnmp.SnmpPDU{Name: oid, Type: gosnmp.NoSuchObject}
+}
+
+// next returns up to n values following oid in MIB order.
+func next(oid string, n int) []gosnmp.SnmpPDU {
+	mib := append([]gosnmp.SnmpPDU(nil), testMIB...)
+	sort.Slice(mib, func(i, j int) bool { return oidLess(mib[i].Name, mib[j].Name) })
+	var pdus []gosnmp.SnmpPDU
+	for _, pdu := range mib {
+		if len(pdus) < n && oidLess(oid, pdu.Name) {
+			pdus = append(pdus, pdu)
+		}
+	}
+	if len(pdus) == 0 {
+		pdus = append(pdus, gosnmp.SnmpPDU{Name: oid, Type: gosnmp.EndOfMibView})
+	}
+	return pdus
+}
+
+func oidLess(a, b string) bool {
+	as := strings.Split(strings.TrimPrefix(a, "."), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "."), ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, _ := strconv.Atoi(as[i])
+		y, _ := strconv.Atoi(bs[i])
+		if x != y {
+			return x < y
+		}
+	}
+	return len(as) < len(bs)
+}
+
+var testOIDs = []domain.SNMPOID{
+	{Name: "sysUpTime", OID: "1.3.6.1.2.1.1.3.0"},
+	{Name: "memTotalReal", OID: ".1.3.6.1.4.1.2021.4.5.0"},
+	{Name: "ssCpuIdle", OID: ".1.3.6.1.4.1.2021.11.11.0"},
+	{Name: "hrStorageUsed", OID: ".1.3.6.1.2.1.25.2.3.1.6", Walk: true},
+	{Name: "ifInErrors", OID: ".1.3.6.1.2.1.2.2.1.14", Walk: true},
+	{Name: "ifHCInOctets", OID: ".1.3.6.1.2.1.31.1.1.1.6", Walk: true},
+}
+
+func checkValues(t *testing.T, values []Value) {
+	t.Helper()
+	got := make(map[string]Value)
+	for _, v := range values {
+		got[v.Name+"/"+v.Instance] = v
+	}
+	want := map[string]Value{
+		"sysUpTime/":      {Value: 1234.56},
+		"memTotalReal/":   {Text: "16384 kB"},
+		"hrStorageUsed/1": {Value: 4096},
+		"ifInErrors/1":    {Value: 7},
+		"ifHCInOctets/1":  {Value: 1 << 40},
+		"ifHCInOctets/2":  {Value: 42},
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d values, want %d: %+v", len(got), len(want), values)
+	}
+	for key, w := range want {
+		v, ok := got[key]
+		if !ok {
+			t.Errorf("%s: missing", key)
+			continue
+		}
+		if v.Value != w.Value || v.Text != w.Text {
+			t.Errorf("%s = %v %q, want %v %q", key, v.Value, v.Text, w.Value, w.Text)
+		}
+	}
+	if _, ok := got["ssCpuIdle/"]; ok {
+		t.Error("unknown scalar ssCpuIdle was not skipped")
+	}
+}
+
+func testPoller() *Poller {
+	p := NewPoller()
+	p.Timeout = time.Second
+	p.Retries = 0
+	return p
+}
+
+func TestPollV2c(t *testing.T) {
+	port := startAgent(t, &agent{community: "secret"})
+	cred := domain.SNMPCredential{Version: domain.SNMPVersion2c, Port: port, Community: "secret"}
+	values, err := testPoller().Poll("127.0.0.1", cred, testOIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, values)
+}
+
+func TestPollV2cWrongCommunity(t *testing.T) {
+	port := startAgent(t, &agent{community: "secret"})
+	cred := domain.SNMPCredential{Version: domain.SNMPVersion2c, Port: port, Community: "public"}
+	if _, err := testPoller().Poll("127.0.0.1", cred, testOIDs); err == nil {
+		t.Fatal("poll with the wrong community succeeded")
+	}
+}
+
+func TestPollV3(t *testing.T) {
+	tests := []struct {
+		name  string
+		cred  domain.SNMPCredential
+		flags gosnmp.SnmpV3MsgFlags
+		usm   *gosnmp.UsmSecurityParameters
+	}{
+		{
+			name:  "noAuthNoPriv",
+			cred:  domain.SNMPCredential{Username: testUser},
+			flags: gosnmp.NoAuthNoPriv,
+			usm: &gosnmp.UsmSecurityParameters{
+				AuthenticationProtocol: gosnmp.NoAuth,
+				PrivacyProtocol:        gosnmp.NoPriv,
+			},
+		},
+		{
+			name:  "authNoPriv",
+			cred:  domain.SNMPCredential{Username: testUser, AuthProtocol: "sha256", AuthPassphrase: testAuthPass},
+			flags: gosnmp.AuthNoPriv,
+			usm: &gosnmp.UsmSecurityParameters{
+				AuthenticationProtocol:   gosnmp.SHA256,
+				AuthenticationPassphrase: testAuthPass,
+				PrivacyProtocol:          gosnmp.NoPriv,
+			},
+		},
+		{
+			name: "authPriv",
+			cred: domain.SNMPCredential{
+				Username: testUser, AuthProtocol: "SHA", AuthPassphrase: testAuthPass,
+				PrivProtocol: "AES", PrivPassphrase: testPrivPass,
+			},
+			flags: gosnmp.AuthPriv,
+			usm: &gosnmp.UsmSecurityParameters{
+				AuthenticationProtocol:   gosnmp.SHA,
+				AuthenticationPassphrase: testAuthPass,
+				PrivacyProtocol:          gosnmp.AES,
+				PrivacyPassphrase:        testPrivPass,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.usm.UserName = testUser
+			tt.usm.AuthoritativeEngineID = testEngineID
+			port := startAgent(t, &agent{usm: tt.usm, flags: tt.flags})
+
+			cred := tt.cred
+			cred.Version = domain.SNMPVersion3
+			cred.Port = port
+			values, err := testPoller().Poll("127.0.0.1", cred, testOIDs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkValues(t, values)
+		})
+	}
+}
+
+func TestValidateCredential(t *testing.T) {
+	tests := []struct {
+		cred domain.SNMPCredential
+		ok   bool
+	}{
+		{domain.SNMPCredential{Version: domain.SNMPVersion2c, Community: "public"}, true},
+		{domain.SNMPCredential{Version: domain.SNMPVersion2c}, false},
+		{domain.SNMPCredential{Version: domain.SNMPVersion3, Username: "u"}, true},
+		{domain.SNMPCredential{Version: domain.SNMPVersion3}, false},
+		{domain.SNMPCredential{Version: domain.SNMPVersion3, Username: "u", PrivProtocol: "AES"}, false},
+		{domain.SNMPCredential{Version: domain.SNMPVersion3, Username: "u", AuthProtocol: "MD4"}, false},
+		{domain.SNMPCredential{Version: "1"}, false},
+	}
+	for _, tt := range tests {
+		if err := ValidateCredential(tt.cred); (err == nil) != tt.ok {
+			t.Errorf("ValidateCredential(%+v) = %v", tt.cred, err)
+		}
+	}
+}
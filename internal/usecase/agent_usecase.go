@@ -0,0 +1,385 @@
+package usecase
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/repository"
+)
+
+// How many agents must see a problem with a device for it to count.
+const (
+	AgentQuorumOne      = "one"
+	AgentQuorumMajority = "majority"
+	AgentQuorumAll      = "all"
+)
+
+// agentCacheTTL is how long agents are cached for RemoteResults before
+// they are read again.
+const agentCacheTTL = 10 * time.Second
+
+// AgentAssignment is a device an agent probes, with the checks to run.
+type AgentAssignment struct {
+	Device domain.Device  `json:"device"`
+	Checks []domain.Check `json:"checks"`
+}
+
+// AgentReport is the outcome of an agent running the checks of a device
+// once.
+type AgentReport struct {
+	DeviceID  uint          `json:"device_id"`
+	CheckedAt time.Time     `json:"checked_at"`
+	Results   []ProbeResult `json:"results"`
+}
+
+// AgentUsecase manages probe agents and turns the results they push into
+// device statuses. Devices covered by an agent are not probed by the
+// server; their scheduled checks combine the fresh results of their agents
+// instead.
+type AgentUsecase struct {
+	Repo    *repository.AgentRepository
+	Devices *DeviceUsecase
+	// Timeout is how long an agent counts as online after its last request
+	// and how long its results are used.
+	Timeout time.Duration
+	Quorum  string
+
+	mu       sync.Mutex
+	loadedAt time.Time
+	agents   []domain.Agent
+}
+
+// NewAgentUsecase reads AGENT_TIMEOUT (default 1m) and AGENT_QUORUM (one,
+// majority or all, default all).
+func NewAgentUsecase(repo *repository.AgentRepository, devices *DeviceUsecase) *AgentUsecase {
+	u := &AgentUsecase{Repo: repo, Devices: devices, Timeout: time.Minute, Quorum: AgentQuorumAll}
+	if v, err := time.ParseDuration(os.Getenv("AGENT_TIMEOUT")); err == nil && v > 0 {
+		u.Timeout = v
+	}
+	switch q := os.Getenv("AGENT_QUORUM"); q {
+	case "":
+	case AgentQuorumOne, AgentQuorumMajority, AgentQuorumAll:
+		u.Quorum = q
+	default:
+		log.Printf("Unknown AGENT_QUORUM %q, using %s", q, u.Quorum)
+	}
+	return u
+}
+
+// CreateAgent saves an agent with a new token, which is only returned
+// this once.
+func (u *AgentUsecase) CreateAgent(a *domain.Agent) error {
+	if a.Name == "" {
+		return &ValidationError{Msg: "name is required"}
+	}
+	token, err := NewToken()
+	if err != nil {
+		return err
+	}
+	a.Token, a.TokenHash = token, hashToken(token)
+	a.Hostname, a.Version, a.RegisteredAt, a.LastSeenAt = "", "", nil, nil
+	if err := u.Repo.CreateAgent(a); err != nil {
+		return err
+	}
+	u.invalidate()
+	return nil
+}
+
+func (u *AgentUsecase) UpdateAgent(a *domain.Agent) error {
+	if a.Name == "" {
+		return &ValidationError{Msg: "name is required"}
+	}
+	if err := u.Repo.UpdateAgent(a); err != nil {
+		return err
+	}
+	u.invalidate()
+	return nil
+}
+
+func (u *AgentUsecase) GetAllAgents() ([]domain.Agent, error) {
+	agents, err := u.Repo.GetAllAgents()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range agents {
+		agents[i].Online = u.online(agents[i], now)
+	}
+	return agents, nil
+}
+
+func (u *AgentUsecase) GetAgentByID(id uint) (*domain.Agent, error) {
+	a, err := u.Repo.GetAgentByID(id)
+	if err != nil {
+		return nil, err
+	}
+	a.Online = u.online(*a, time.Now())
+	return a, nil
+}
+
+func (u *AgentUsecase) DeleteAgent(id uint) error {
+	if err := u.Repo.DeleteAgent(id); err != nil {
+		return err
+	}
+	u.invalidate()
+	return nil
+}
+
+// RotateToken gives an agent a new token; the old one stops working.
+func (u *AgentUsecase) RotateToken(id uint) (*domain.Agent, error) {
+	a, err := u.GetAgentByID(id)
+	if err != nil {
+		return nil, err
+	}
+	token, err := NewToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.Repo.UpdateAgentFields(id, map[string]interface{}{"token_hash": hashToken(token)}); err != nil {
+		return nil, err
+	}
+	a.Token = token
+	return a, nil
+}
+
+// GetAgentResults returns the latest results an agent reported.
+func (u *AgentUsecase) GetAgentResults(id uint) ([]domain.AgentResult, error) {
+	if _, err := u.Repo.GetAgentByID(id); err != nil {
+		return nil, err
+	}
+	return u.Repo.GetResultsByAgent(id)
+}
+
+// Authenticate returns the enabled agent with token and marks it as seen.
+func (u *AgentUsecase) Authenticate(token string) (*domain.Agent, error) {
+	if token == "" {
+		return nil, &ValidationError{Msg: "missing agent token"}
+	}
+	a, err := u.Repo.GetAgentByTokenHash(hashToken(token))
+	if err != nil {
+		return nil, &ValidationError{Msg: "invalid agent token"}
+	}
+	if a.Disabled {
+		return nil, &ValidationError{Msg: "agent is disabled"}
+	}
+	now := time.Now()
+	if err := u.Repo.UpdateAgentFields(a.ID, map[string]interface{}{"last_seen_at": now}); err != nil {
+		log.Printf("Error updating agent %s: %v", a.Name, err)
+	}
+	a.LastSeenAt = &now
+	a.Online = true
+	return a, nil
+}
+
+// Register records the host and version an agent runs on when it starts.
+func (u *AgentUsecase) Register(a *domain.Agent, hostname, version string) error {
+	now := time.Now()
+	if err := u.Repo.UpdateAgentFields(a.ID, map[string]interface{}{
+		"hostname":      hostname,
+		"version":       version,
+		"registered_at": now,
+	}); err != nil {
+		return err
+	}
+	a.Hostname, a.Version, a.RegisteredAt = hostname, version, &now
+	log.Printf("Agent %s registered from %s (%s)", a.Name, hostname, version)
+	return nil
+}
+
+// GetAssignments returns the devices an agent probes with their enabled
+// checks, or their implicit checks when they have none. Heartbeat checks
+// are left to the server, and devices with nothing else to check are not
+// assigned.
+func (u *AgentUsecase) GetAssignments(a domain.Agent) ([]AgentAssignment, error) {
+	devices, err := u.Devices.Repo.GetAllDevices()
+	if err != nil {
+		return nil, err
+	}
+	assignments := []AgentAssignment{}
+	for _, device := range devices {
+		if !a.Covers(device) {
+			continue
+		}
+		checks, err := u.Devices.CheckRepo.GetChecksByDevice(device.ID)
+		if err != nil {
+			return nil, err
+		}
+		_, checks = splitHeartbeatChecks(EnabledChecks(device, checks))
+		if len(checks) == 0 {
+			continue
+		}
+		for i := range checks {
+			checks[i].Token = nil
+		}
+		assignments = append(assignments, AgentAssignment{Device: device, Checks: checks})
+	}
+	return assignments, nil
+}
+
+// ReportResults stores the results pushed by an agent and returns how
+// many reports were accepted. Reports for devices the agent does not
+// cover, for example because it was reassigned in the meantime, and
+// results of checks that do not belong to the device or are heartbeat
+// checks are dropped.
+func (u *AgentUsecase) ReportResults(a domain.Agent, reports []AgentReport) (int, error) {
+	now := time.Now()
+	rows := make([]domain.AgentResult, 0, len(reports))
+	for _, rep := range reports {
+		device, err := u.Devices.Repo.GetDeviceByID(rep.DeviceID)
+		if err != nil || !a.Covers(*device) {
+			continue
+		}
+		checks, err := u.Devices.CheckRepo.GetChecksByDevice(device.ID)
+		if err != nil {
+			return 0, err
+		}
+		owned := make(map[uint]bool, len(checks))
+		for _, check := range checks {
+			owned[check.ID] = check.Type != domain.CheckTypeHeartbeat
+		}
+		results := make([]ProbeResult, 0, len(rep.Results))
+		for _, res := range rep.Results {
+			if res.CheckID != 0 && !owned[res.CheckID] {
+				continue
+			}
+			if res.Certificate != nil {
+				res.Certificate.DeviceID = device.ID
+			}
+			results = append(results, res)
+		}
+		if rep.CheckedAt.IsZero() || rep.CheckedAt.After(now) {
+			rep.CheckedAt = now
+		}
+		u.Devices.storeResults(*device, results, rep.CheckedAt)
+		encoded, err := json.Marshal(results)
+		if err != nil {
+			return 0, err
+		}
+		rows = append(rows, domain.AgentResult{
+			AgentID:    a.ID,
+			DeviceID:   device.ID,
+			Status:     DeriveStatus(results),
+			Results:    encoded,
+			CheckedAt:  rep.CheckedAt,
+			ReportedAt: now,
+		})
+	}
+	if err := u.Repo.UpsertResults(rows); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+// RemoteResults implements RemoteProber. A device covered by agents gets
+// the status its agents agree on under the quorum. When none of them
+// reported within Timeout the device is unreachable, since its state is
+// unknown rather than down.
+func (u *AgentUsecase) RemoteResults(device domain.Device, now time.Time) ([]ProbeResult, string, bool) {
+	names := make(map[uint]string)
+	for _, a := range u.cachedAgents(now) {
+		if a.Covers(device) {
+			names[a.ID] = a.Name
+		}
+	}
+	if len(names) == 0 {
+		return nil, "", false
+	}
+	rows, err := u.Repo.GetResultsByDevice(device.ID)
+	if err != nil {
+		log.Printf("Error fetching agent results for device %s: %v", device.Name, err)
+	}
+	var results []ProbeResult
+	var statuses []string
+	for _, row := range rows {
+		name, ok := names[row.AgentID]
+		if !ok || now.Sub(row.ReportedAt) > u.Timeout {
+			continue
+		}
+		var rs []ProbeResult
+		if err := json.Unmarshal(row.Results, &rs); err != nil {
+			log.Printf("Error decoding results of agent %s: %v", name, err)
+			continue
+		}
+		for i := range rs {
+			if rs[i].Details == nil {
+				rs[i].Details = make(map[string]interface{})
+			}
+			rs[i].Details["agent"] = name
+		}
+		results = append(results, rs...)
+		statuses = append(statuses, row.Status)
+	}
+	if len(statuses) == 0 {
+		return nil, domain.StatusUnreachable, true
+	}
+	return results, combineStatuses(statuses, u.Quorum), true
+}
+
+// combineStatuses returns the worst status that the quorum of agents
+// reports, counting each agent that reports something worse as agreeing:
+// the worst status for one, the median for majority and the best for all.
+func combineStatuses(statuses []string, quorum string) string {
+	sorted := append([]string(nil), statuses...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return statusRank(sorted[i]) < statusRank(sorted[j])
+	})
+	switch quorum {
+	case AgentQuorumOne:
+		return sorted[len(sorted)-1]
+	case AgentQuorumMajority:
+		return sorted[(len(sorted)-1)/2]
+	}
+	return sorted[0]
+}
+
+// statusRank orders the statuses derived from check results from best to
+// worst.
+func statusRank(status string) int {
+	switch status {
+	case domain.StatusOnline:
+		return 0
+	case domain.StatusWarning:
+		return 1
+	case domain.StatusDegraded:
+		return 2
+	}
+	return 3
+}
+
+func (u *AgentUsecase) online(a domain.Agent, now time.Time) bool {
+	return !a.Disabled && a.LastSeenAt != nil && now.Sub(*a.LastSeenAt) <= u.Timeout
+}
+
+// cachedAgents returns the agents, reading them again when the cache is
+// older than agentCacheTTL.
+func (u *AgentUsecase) cachedAgents(now time.Time) []domain.Agent {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.loadedAt.IsZero() || now.Sub(u.loadedAt) >= agentCacheTTL {
+		agents, err := u.Repo.GetAllAgents()
+		if err != nil {
+			log.Printf("Error fetching agents: %v", err)
+			return u.agents
+		}
+		u.agents, u.loadedAt = agents, now
+	}
+	return u.agents
+}
+
+func (u *AgentUsecase) invalidate() {
+	u.mu.Lock()
+	u.loadedAt = time.Time{}
+	u.mu.Unlock()
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
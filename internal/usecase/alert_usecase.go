@@ -0,0 +1,574 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/repository"
+)
+
+// AlertUsecase manages alert rules and raises and resolves alerts from the
+// results of device checks, which Run evaluates off the probing path.
+// Rules, open alerts, device types and location names are cached; the
+// cache is refreshed whenever rules change and after alertCacheTTL, so
+// changes made by other instances are seen.
+type AlertUsecase struct {
+	Repo           *repository.AlertRepository
+	EscalationRepo *repository.EscalationRepository
+	DeviceRepo     *repository.DeviceRepository
+	TypeMapRepo    *repository.DeviceTypeMapRepository
+	LocationRepo   *repository.LocationRepository
+	CertRepo       *repository.CertificateRepository
+	LogRepo        *repository.LogRepository
+
+	Listeners  []AlertListener
+	Suppressor AlertSuppressor // may be nil
+
+	queue chan checkedDevice
+
+	mu        sync.Mutex
+	loadedAt  time.Time
+	rules     []domain.AlertRule
+	open      map[string]*domain.Alert // open alerts by key
+	types     map[uint][]uint          // device ID -> type IDs
+	locations map[uint]string          // location ID -> name
+	since     map[uint]statusSince     // current status of each device
+	breach    map[string]time.Time     // alert key -> start of a latency breach
+}
+
+// alertCacheTTL is how long rules, open alerts, device types and location
+// names are cached before they are read again.
+const alertCacheTTL = 30 * time.Second
+
+// alertQueueSize is how many check results may wait for evaluation before
+// DeviceChecked blocks.
+const alertQueueSize = 1024
+
+// checkedDevice is a check result waiting for evaluation.
+type checkedDevice struct {
+	device domain.Device
+	report CheckReport
+}
+
+// AlertListener is told whenever an alert starts firing, is acknowledged
+// or is resolved; alert.State tells which. It is called with the alert
+// engine locked and must not block.
+type AlertListener interface {
+	AlertChanged(alert domain.Alert)
+}
+
+// AlertSuppressor holds back alerts, e.g. during maintenance. Suppressed
+// alerts are not raised; alerts that are already open are not affected.
+type AlertSuppressor interface {
+	SuppressAlert(alert domain.Alert, at time.Time) bool
+}
+
+// alertSubject is what an alert is about: a device or a location.
+type alertSubject struct {
+	deviceID   uint
+	deviceName string
+	locationID uint
+}
+
+type statusSince struct {
+	status string
+	since  time.Time
+}
+
+func NewAlertUsecase(repo *repository.AlertRepository, escalationRepo *repository.EscalationRepository, deviceRepo *repository.DeviceRepository, typeMapRepo *repository.DeviceTypeMapRepository, locationRepo *repository.LocationRepository, certRepo *repository.CertificateRepository, logRepo *repository.LogRepository) *AlertUsecase {
+	return &AlertUsecase{
+		Repo:           repo,
+		EscalationRepo: escalationRepo,
+		DeviceRepo:     deviceRepo,
+		TypeMapRepo:    typeMapRepo,
+		LocationRepo:   locationRepo,
+		CertRepo:       certRepo,
+		LogRepo:        logRepo,
+		queue:          make(chan checkedDevice, alertQueueSize),
+		since:          make(map[uint]statusSince),
+		breach:         make(map[string]time.Time),
+	}
+}
+
+// AddListener registers l to be told about alert changes.
+func (u *AlertUsecase) AddListener(l AlertListener) {
+	u.Listeners = append(u.Listeners, l)
+}
+
+func (u *AlertUsecase) CreateRule(rule *domain.AlertRule) error {
+	if err := u.validateRule(rule); err != nil {
+		return err
+	}
+	if err := u.Repo.CreateRule(rule); err != nil {
+		return err
+	}
+	u.reload()
+	return nil
+}
+
+// UpdateRule saves rule. Open alerts of a rule that gets disabled are
+// resolved.
+func (u *AlertUsecase) UpdateRule(rule *domain.AlertRule) error {
+	if err := u.validateRule(rule); err != nil {
+		return err
+	}
+	if err := u.Repo.UpdateRule(rule); err != nil {
+		return err
+	}
+	if rule.Disabled {
+		u.resolveRule(rule.ID)
+	}
+	u.reload()
+	return nil
+}
+
+func (u *AlertUsecase) GetAllRules() ([]domain.AlertRule, error) {
+	return u.Repo.GetAllRules()
+}
+
+func (u *AlertUsecase) GetRuleByID(id uint) (*domain.AlertRule, error) {
+	return u.Repo.GetRuleByID(id)
+}
+
+// DeleteRule deletes a rule and resolves its open alerts.
+func (u *AlertUsecase) DeleteRule(id uint) error {
+	if err := u.Repo.DeleteRule(id); err != nil {
+		return err
+	}
+	u.resolveRule(id)
+	u.reload()
+	return nil
+}
+
+func (u *AlertUsecase) GetAlerts(f repository.AlertFilter) ([]domain.Alert, error) {
+	return u.Repo.GetAlerts(f)
+}
+
+func (u *AlertUsecase) GetAlertByID(id uint) (*domain.Alert, error) {
+	return u.Repo.GetAlertByID(id)
+}
+
+// AcknowledgeAlert marks a firing alert as acknowledged by by. It stays
+// open until its condition clears.
+func (u *AlertUsecase) AcknowledgeAlert(id uint, by string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	alert, err := u.Repo.GetAlertByID(id)
+	if err != nil {
+		return err
+	}
+	if alert.State != domain.AlertFiring {
+		return &ValidationError{Msg: fmt.Sprintf("alert is %s, not firing", alert.State)}
+	}
+	now := time.Now()
+	if err := u.Repo.UpdateAlert(id, map[string]interface{}{
+		"state":           domain.AlertAcknowledged,
+		"acknowledged_at": now,
+		"acknowledged_by": by,
+	}); err != nil {
+		return err
+	}
+	if open, ok := u.open[alert.Key]; ok && open.ID == id {
+		open.State = domain.AlertAcknowledged
+		open.AcknowledgedAt = &now
+		open.AcknowledgedBy = by
+	}
+	alert.State = domain.AlertAcknowledged
+	alert.AcknowledgedAt = &now
+	alert.AcknowledgedBy = by
+	u.notify(*alert)
+	return nil
+}
+
+// DeviceChecked queues the result of a device check for Run, so checks do
+// not wait for the rules to be evaluated.
+func (u *AlertUsecase) DeviceChecked(device domain.Device, report CheckReport) {
+	u.queue <- checkedDevice{device: device, report: report}
+}
+
+// Run evaluates queued check results until ctx is cancelled. Results that
+// queue up while a batch is evaluated form the next batch.
+func (u *AlertUsecase) Run(ctx context.Context) {
+	for {
+		var batch []checkedDevice
+		select {
+		case <-ctx.Done():
+			return
+		case c := <-u.queue:
+			batch = append(batch, c)
+		}
+	drain:
+		for len(batch) < alertQueueSize {
+			select {
+			case c := <-u.queue:
+				batch = append(batch, c)
+			default:
+				break drain
+			}
+		}
+		u.evaluate(batch)
+	}
+}
+
+// evaluate checks every enabled rule in scope of each device of batch
+// against its latest result, and resolves the alerts a device has under
+// rules it is no longer in scope of. Location rules are evaluated once per
+// batch for the locations of the devices in it.
+func (u *AlertUsecase) evaluate(batch []checkedDevice) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err := u.load(); err != nil {
+		log.Printf("Error loading alert rules: %v", err)
+		return
+	}
+	now := time.Now()
+	locations := make(map[uint]bool)
+	for _, c := range batch {
+		device := c.device
+		since := u.statusSince(device, c.report, now)
+		for _, rule := range u.rules {
+			if rule.Disabled || !rule.Matches(device, u.types[device.ID]) {
+				key := deviceAlertKey(rule, device.ID)
+				delete(u.breach, key)
+				if open := u.open[key]; open != nil {
+					u.resolve(open, now)
+				}
+				continue
+			}
+			switch rule.Condition {
+			case domain.AlertConditionOffline:
+				u.evaluateOffline(rule, device, c.report, since, now)
+			case domain.AlertConditionLatency:
+				u.evaluateLatency(rule, device, c.report, now)
+			case domain.AlertConditionCertExpiry:
+				u.evaluateCertExpiry(rule, device, now)
+			case domain.AlertConditionLocationDown:
+				if device.LocationID != 0 {
+					locations[device.LocationID] = true
+				}
+			}
+		}
+	}
+	if len(locations) > 0 {
+		u.evaluateLocations(locations, now)
+	}
+}
+
+// evaluateOffline fires for devices that are offline, and for devices
+// that are unreachable because none of their agents reports any more, so
+// a silent agent does not go unnoticed.
+func (u *AlertUsecase) evaluateOffline(rule domain.AlertRule, device domain.Device, report CheckReport, since time.Time, now time.Time) {
+	down := now.Sub(since)
+	silent := device.Status == domain.StatusUnreachable && report.AgentsSilent
+	firing := (device.Status == domain.StatusOffline || silent) && down >= time.Duration(rule.ForMinutes)*time.Minute
+	msg := fmt.Sprintf("Device %s is offline", device.Name)
+	if silent {
+		msg = fmt.Sprintf("Device %s is unreachable: none of its agents reported", device.Name)
+	}
+	u.setAlert(rule, deviceAlertKey(rule, device.ID), deviceSubject(device), firing, down.Minutes(), msg, now)
+}
+
+func (u *AlertUsecase) evaluateLatency(rule domain.AlertRule, device domain.Device, report CheckReport, now time.Time) {
+	key := deviceAlertKey(rule, device.ID)
+	latency, measured := 0.0, false
+	for _, c := range report.Checks {
+		if c.Status == domain.StatusOffline || (rule.CheckType != "" && c.Type != rule.CheckType) {
+			continue
+		}
+		latency, measured = max(latency, c.LatencyMs), true
+	}
+	if !measured || latency <= rule.Threshold {
+		delete(u.breach, key)
+		u.setAlert(rule, key, deviceSubject(device), false, latency, "", now)
+		return
+	}
+	start, ok := u.breach[key]
+	if !ok {
+		start = now
+		u.breach[key] = start
+	}
+	firing := now.Sub(start) >= time.Duration(rule.ForMinutes)*time.Minute
+	msg := fmt.Sprintf("Latency of device %s is above %.0f ms", device.Name, rule.Threshold)
+	u.setAlert(rule, key, deviceSubject(device), firing, latency, msg, now)
+}
+
+func (u *AlertUsecase) evaluateCertExpiry(rule domain.AlertRule, device domain.Device, now time.Time) {
+	certs, err := u.CertRepo.GetCertificatesByDevice(device.ID)
+	if err != nil {
+		log.Printf("Error fetching certificates of device %s: %v", device.Name, err)
+		return
+	}
+	var soonest *domain.Certificate
+	for i := range certs {
+		if soonest == nil || certs[i].NotAfter.Before(soonest.NotAfter) {
+			soonest = &certs[i]
+		}
+	}
+	if soonest == nil {
+		u.setAlert(rule, deviceAlertKey(rule, device.ID), deviceSubject(device), false, 0, "", now)
+		return
+	}
+	days := soonest.NotAfter.Sub(now).Hours() / 24
+	firing := days < rule.Threshold
+	msg := fmt.Sprintf("Certificate of %s:%d on device %s expires on %s",
+		soonest.Host, soonest.Port, device.Name, soonest.NotAfter.Format(time.DateOnly))
+	u.setAlert(rule, deviceAlertKey(rule, device.ID), deviceSubject(device), firing, days, msg, now)
+}
+
+// evaluateLocations evaluates the location rules for each of locations,
+// reading the devices once.
+func (u *AlertUsecase) evaluateLocations(locations map[uint]bool, now time.Time) {
+	devices, err := u.DeviceRepo.GetAllDevices()
+	if err != nil {
+		log.Printf("Error fetching devices: %v", err)
+		return
+	}
+	byLocation := make(map[uint][]domain.Device)
+	for _, d := range devices {
+		if locations[d.LocationID] {
+			byLocation[d.LocationID] = append(byLocation[d.LocationID], d)
+		}
+	}
+	for _, rule := range u.rules {
+		if rule.Disabled || rule.Condition != domain.AlertConditionLocationDown {
+			continue
+		}
+		for locationID := range locations {
+			u.evaluateLocation(rule, locationID, byLocation[locationID], now)
+		}
+	}
+}
+
+// evaluateLocation counts the devices of a location that are in the rule's
+// scope and fires when more than Threshold percent of them are offline.
+func (u *AlertUsecase) evaluateLocation(rule domain.AlertRule, locationID uint, devices []domain.Device, now time.Time) {
+	total, down := 0, 0
+	for _, d := range devices {
+		if !rule.Matches(d, u.types[d.ID]) {
+			continue
+		}
+		total++
+		if isDown(d.Status) {
+			down++
+		}
+	}
+	pct := 0.0
+	if total > 0 {
+		pct = float64(down) / float64(total) * 100
+	}
+	name, ok := u.locations[locationID]
+	if !ok {
+		name = fmt.Sprint(locationID)
+	}
+	msg := fmt.Sprintf("%d of %d devices in location %s are offline", down, total, name)
+	key := fmt.Sprintf("%d:location:%d", rule.ID, locationID)
+	u.setAlert(rule, key, alertSubject{locationID: locationID}, total > 0 && pct > rule.Threshold, pct, msg, now)
+}
+
+// setAlert raises an alert for key when firing and none is open, and
+// resolves the open one when not firing.
+func (u *AlertUsecase) setAlert(rule domain.AlertRule, key string, subject alertSubject, firing bool, value float64, msg string, now time.Time) {
+	open := u.open[key]
+	switch {
+	case firing && open == nil:
+		alert := &domain.Alert{
+			RuleID:     rule.ID,
+			Key:        key,
+			DeviceID:   subject.deviceID,
+			LocationID: subject.locationID,
+			Severity:   rule.Severity,
+			State:      domain.AlertFiring,
+			Message:    msg,
+			Value:      value,
+			StartedAt:  now,
+			RuleName:   rule.Name,
+			DeviceName: subject.deviceName,
+		}
+		if u.Suppressor != nil && u.Suppressor.SuppressAlert(*alert, now) {
+			return
+		}
+		if err := u.Repo.CreateAlert(alert); err != nil {
+			log.Printf("Error creating alert %s: %v", key, err)
+			return
+		}
+		u.open[key] = alert
+		log.Printf("Alert firing: %s", msg)
+		u.notify(*alert)
+	case firing && open.Message != msg:
+		if err := u.Repo.UpdateAlert(open.ID, map[string]interface{}{"message": msg, "value": value}); err != nil {
+			log.Printf("Error updating alert %d: %v", open.ID, err)
+			return
+		}
+		open.Message, open.Value = msg, value
+	case !firing && open != nil:
+		u.resolve(open, now)
+	}
+}
+
+func (u *AlertUsecase) resolve(alert *domain.Alert, now time.Time) {
+	if err := u.Repo.UpdateAlert(alert.ID, map[string]interface{}{
+		"state":       domain.AlertResolved,
+		"resolved_at": now,
+	}); err != nil {
+		log.Printf("Error resolving alert %d: %v", alert.ID, err)
+		return
+	}
+	alert.State = domain.AlertResolved
+	alert.ResolvedAt = &now
+	delete(u.open, alert.Key)
+	log.Printf("Alert resolved: %s", alert.Message)
+	u.notify(*alert)
+}
+
+func (u *AlertUsecase) notify(alert domain.Alert) {
+	for _, l := range u.Listeners {
+		l.AlertChanged(alert)
+	}
+}
+
+func (u *AlertUsecase) resolveRule(ruleID uint) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err := u.load(); err != nil {
+		log.Printf("Error loading alerts: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, alert := range u.open {
+		if alert.RuleID == ruleID {
+			u.resolve(alert, now)
+		}
+	}
+}
+
+// ResolveDevice resolves the open alerts of a device that is deleted and
+// forgets its state.
+func (u *AlertUsecase) ResolveDevice(deviceID uint) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err := u.load(); err != nil {
+		log.Printf("Error loading alerts: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, alert := range u.open {
+		if alert.DeviceID == deviceID {
+			u.resolve(alert, now)
+		}
+	}
+	for _, rule := range u.rules {
+		delete(u.breach, deviceAlertKey(rule, deviceID))
+	}
+	delete(u.since, deviceID)
+}
+
+// statusSince returns since when device has had its current status,
+// falling back to the log for devices not seen since startup.
+func (u *AlertUsecase) statusSince(device domain.Device, report CheckReport, now time.Time) time.Time {
+	cur, ok := u.since[device.ID]
+	switch {
+	case report.OldStatus != report.Status:
+		cur = statusSince{status: report.Status, since: now}
+	case !ok || cur.status != report.Status:
+		cur = statusSince{status: report.Status, since: now}
+		if last, err := u.LogRepo.GetLastTransition(device.ID); err == nil && last != nil && last.NewStatus == report.Status {
+			cur.since = last.Logtime
+		}
+	}
+	u.since[device.ID] = cur
+	return cur.since
+}
+
+// load fills the caches on first use and when they are older than
+// alertCacheTTL. Callers hold u.mu.
+func (u *AlertUsecase) load() error {
+	now := time.Now()
+	if !u.loadedAt.IsZero() && now.Sub(u.loadedAt) < alertCacheTTL {
+		return nil
+	}
+	rules, err := u.Repo.GetAllRules()
+	if err != nil {
+		return err
+	}
+	alerts, err := u.Repo.GetOpenAlerts()
+	if err != nil {
+		return err
+	}
+	maps, err := u.TypeMapRepo.GetAllMaps()
+	if err != nil {
+		return err
+	}
+	locations, err := u.LocationRepo.GetAllLocations()
+	if err != nil {
+		return err
+	}
+	u.rules = rules
+	u.types = make(map[uint][]uint)
+	for _, m := range maps {
+		u.types[m.DeviceID] = append(u.types[m.DeviceID], m.TypeID)
+	}
+	u.locations = make(map[uint]string, len(locations))
+	for _, loc := range locations {
+		u.locations[loc.ID] = loc.Name
+	}
+	u.open = make(map[string]*domain.Alert, len(alerts))
+	for i := range alerts {
+		u.open[alerts[i].Key] = &alerts[i]
+	}
+	u.loadedAt = now
+	return nil
+}
+
+// reload drops the caches so the next evaluation reads the rules again.
+func (u *AlertUsecase) reload() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.loadedAt = time.Time{}
+}
+
+func deviceSubject(device domain.Device) alertSubject {
+	return alertSubject{deviceID: device.ID, deviceName: device.Name, locationID: device.LocationID}
+}
+
+func deviceAlertKey(rule domain.AlertRule, deviceID uint) string {
+	return fmt.Sprintf("%d:device:%d", rule.ID, deviceID)
+}
+
+func (u *AlertUsecase) validateRule(rule *domain.AlertRule) error {
+	if rule.Name == "" {
+		return &ValidationError{Msg: "name is required"}
+	}
+	switch rule.Severity {
+	case "":
+		rule.Severity = domain.SeverityWarning
+	case domain.SeverityInfo, domain.SeverityWarning, domain.SeverityCritical:
+	default:
+		return &ValidationError{Msg: fmt.Sprintf("unknown severity %q", rule.Severity)}
+	}
+	if rule.ForMinutes < 0 {
+		return &ValidationError{Msg: "for_minutes must not be negative"}
+	}
+	switch rule.Condition {
+	case domain.AlertConditionOffline:
+	case domain.AlertConditionLatency, domain.AlertConditionCertExpiry:
+		if rule.Threshold <= 0 {
+			return &ValidationError{Msg: "threshold must be positive"}
+		}
+	case domain.AlertConditionLocationDown:
+		if rule.Threshold < 0 || rule.Threshold >= 100 {
+			return &ValidationError{Msg: "threshold must be a percentage from 0 to 100"}
+		}
+	default:
+		return &ValidationError{Msg: fmt.Sprintf("unknown condition %q", rule.Condition)}
+	}
+	if rule.EscalationPolicyID != 0 {
+		if _, err := u.EscalationRepo.GetPolicyByID(rule.EscalationPolicyID); err != nil {
+			return &ValidationError{Msg: fmt.Sprintf("escalation policy %d does not exist", rule.EscalationPolicyID)}
+		}
+	}
+	return nil
+}
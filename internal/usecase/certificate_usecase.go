@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/repository"
+)
+
+type CertificateUsecase struct {
+	Repo     *repository.CertificateRepository
+	WarnDays int
+}
+
+func NewCertificateUsecase(repo *repository.CertificateRepository) *CertificateUsecase {
+	return &CertificateUsecase{Repo: repo, WarnDays: certWarnDaysFromEnv()}
+}
+
+// GetExpiringCertificates lists certificates expiring within days, or
+// within the configured warning period when days is not positive.
+// Already expired certificates are included.
+func (u *CertificateUsecase) GetExpiringCertificates(days int) ([]domain.Certificate, error) {
+	if days <= 0 {
+		days = u.WarnDays
+	}
+	return u.Repo.GetCertificatesExpiringBefore(time.Now().AddDate(0, 0, days))
+}
+
+func (u *CertificateUsecase) GetCertificatesByDevice(deviceID uint) ([]domain.Certificate, error) {
+	return u.Repo.GetCertificatesByDevice(deviceID)
+}
@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/repository"
+)
+
+type CheckUsecase struct {
+	Repo       *repository.CheckRepository
+	DeviceRepo *repository.DeviceRepository
+	CertRepo   *repository.CertificateRepository
+	Probers    map[string]Prober
+}
+
+func NewCheckUsecase(repo *repository.CheckRepository, deviceRepo *repository.DeviceRepository, certRepo *repository.CertificateRepository, probers map[string]Prober) *CheckUsecase {
+	return &CheckUsecase{Repo: repo, DeviceRepo: deviceRepo, CertRepo: certRepo, Probers: probers}
+}
+
+// CreateCheck saves a check for an existing device. Result fields cannot
+// be set; they are filled in when the check runs. Heartbeat checks get a
+// token for their push URL.
+func (u *CheckUsecase) CreateCheck(check *domain.Check) error {
+	if err := u.validate(check); err != nil {
+		return err
+	}
+	if _, err := u.DeviceRepo.GetDeviceByID(check.DeviceID); err != nil {
+		return &ValidationError{Msg: fmt.Sprintf("device %d does not exist", check.DeviceID)}
+	}
+	check.LastStatus, check.LastError, check.LastLatencyMs = "", "", 0
+	check.LastDetails, check.LastCheckedAt, check.LastHeartbeatAt = nil, nil, nil
+	check.Token = nil
+	if check.Type == domain.CheckTypeHeartbeat {
+		token, err := NewToken()
+		if err != nil {
+			return err
+		}
+		check.Token = &token
+	}
+	return u.Repo.CreateCheck(check)
+}
+
+// UpdateCheck saves a check. Tokens cannot be set; a check that becomes a
+// heartbeat check gets one. The certificate of a TLS check is dropped
+// when the check stops probing its host and port.
+func (u *CheckUsecase) UpdateCheck(check *domain.Check) error {
+	if err := u.validate(check); err != nil {
+		return err
+	}
+	old, err := u.Repo.GetCheckByID(check.ID)
+	if err != nil {
+		return err
+	}
+	check.Token = old.Token
+	if check.Type == domain.CheckTypeHeartbeat && check.Token == nil {
+		token, err := NewToken()
+		if err != nil {
+			return err
+		}
+		check.Token = &token
+	}
+	if err := u.Repo.UpdateCheck(check); err != nil {
+		return err
+	}
+	if old.Type == domain.CheckTypeTLS && (check.Type != domain.CheckTypeTLS || tlsEndpoint(*old) != tlsEndpoint(*check)) {
+		return u.CertRepo.DeleteCertificatesByCheck(check.ID)
+	}
+	return nil
+}
+
+// tlsEndpoint returns the host and port set on a TLS check; empty ones
+// default to the device's IP and 443 when probing.
+func tlsEndpoint(check domain.Check) string {
+	var params TLSParams
+	decodeParams(check, &params)
+	return fmt.Sprintf("%s:%d", params.Host, params.Port)
+}
+
+// RotateToken gives a heartbeat check a new token; the old push URL stops
+// working.
+func (u *CheckUsecase) RotateToken(id uint) (*domain.Check, error) {
+	check, err := u.Repo.GetCheckByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if check.Type != domain.CheckTypeHeartbeat {
+		return nil, &ValidationError{Msg: "only heartbeat checks have a token"}
+	}
+	token, err := NewToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.Repo.UpdateCheckResult(id, map[string]interface{}{"token": token}); err != nil {
+		return nil, err
+	}
+	check.Token = &token
+	return check, nil
+}
+
+func (u *CheckUsecase) GetCheckByID(id uint) (*domain.Check, error) {
+	return u.Repo.GetCheckByID(id)
+}
+
+func (u *CheckUsecase) GetChecksByDevice(deviceID uint) ([]domain.Check, error) {
+	return u.Repo.GetChecksByDevice(deviceID)
+}
+
+// DeleteCheck deletes a check and the certificate it collected.
+func (u *CheckUsecase) DeleteCheck(id uint) error {
+	if err := u.CertRepo.DeleteCertificatesByCheck(id); err != nil {
+		return err
+	}
+	return u.Repo.DeleteCheck(id)
+}
+
+func (u *CheckUsecase) validate(check *domain.Check) error {
+	prober, ok := u.Probers[check.Type]
+	if !ok {
+		return &ValidationError{Msg: fmt.Sprintf("unknown check type %q", check.Type)}
+	}
+	if v, ok := prober.(ParamsValidator); ok {
+		if err := v.ValidateParams(*check); err != nil {
+			return &ValidationError{Msg: err.Error()}
+		}
+	}
+	return nil
+}
+
+// NewToken returns a random hex token for push URLs and API credentials.
+func NewToken() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ValidationError marks errors caused by invalid input rather than by the
+// database, so handlers can answer with 400 instead of 500.
+type ValidationError struct {
+	Msg string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Msg
+}
@@ -1,34 +1,109 @@
 package usecase
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
-	"net/http"
-	"os/exec"
-	"strings"
+	"sort"
 	"time"
 
-	"github.com/gin-gonic/gin"
-
-	// "encoding/json"
-
 	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/events"
 	"github.com/simonaditiabbp/netmon-backend/internal/repository"
 )
 
 type DeviceUsecase struct {
-	Repo             *repository.DeviceRepository
-	TypeMapRepo      *repository.DeviceTypeMapRepository
-	TypeRepo         *repository.DeviceTypeRepository
-	BroadcastChannel chan gin.H
+	Repo        *repository.DeviceRepository
+	TypeMapRepo *repository.DeviceTypeMapRepository
+	TypeRepo    *repository.DeviceTypeRepository
+	CheckRepo   *repository.CheckRepository
+	CertRepo    *repository.CertificateRepository
+	MetricRepo  *repository.MetricRepository
+	SNMPRepo    *repository.SNMPRepository
+	AgentRepo   *repository.AgentRepository
+	Probers     map[string]Prober
+	Tracker     *StatusTracker
+	Hub         *events.Hub
+	Listeners   []CheckListener
+	Remote      RemoteProber  // may be nil
+	Guard       CheckGuard    // may be nil
+	Leader      Leadership    // may be nil when this is the only instance
+	Alerts      AlertResolver // may be nil
+}
+
+var (
+	// ErrCheckRunning is returned by CheckDeviceNow while the device is
+	// being checked already.
+	ErrCheckRunning = errors.New("a check of this device is already running")
+	// ErrNotLeader is returned by CheckDeviceNow on instances that do not
+	// probe devices.
+	ErrNotLeader = errors.New("this instance is not the leader; devices are checked by the leader")
+)
+
+// Leadership tells whether this instance is the one that probes devices
+// and evaluates alerts when several share the database.
+type Leadership interface {
+	IsLeader() bool
+}
+
+// CheckGuard keeps checks requested outside the schedule from running at
+// the same time as a scheduled check of the same device.
+type CheckGuard interface {
+	TryStart(id uint) bool
+	Done(id uint)
+}
+
+// AlertResolver resolves the open alerts of devices that are deleted, so
+// they do not stay firing.
+type AlertResolver interface {
+	ResolveDevice(deviceID uint)
 }
 
-func NewDeviceUsecase(repo *repository.DeviceRepository, typeMapRepo *repository.DeviceTypeMapRepository, typeRepo *repository.DeviceTypeRepository) *DeviceUsecase {
-	return &DeviceUsecase{
-		Repo:             repo,
-		TypeMapRepo:      typeMapRepo,
-		TypeRepo:         typeRepo,
-		BroadcastChannel: make(chan gin.H, 30), // Initialize buffered channel
+// RemoteProber supplies the results of devices that are probed somewhere
+// else than on this server.
+type RemoteProber interface {
+	// RemoteResults returns the latest results for device and the status
+	// they add up to. ok is false for devices the server probes itself.
+	RemoteResults(device domain.Device, now time.Time) (results []ProbeResult, status string, ok bool)
+}
+
+// CheckListener is told about every completed check of a device, after
+// its new status has been stored.
+type CheckListener interface {
+	DeviceChecked(device domain.Device, report CheckReport)
+}
+
+func NewDeviceUsecase(repo *repository.DeviceRepository, typeMapRepo *repository.DeviceTypeMapRepository, typeRepo *repository.DeviceTypeRepository, checkRepo *repository.CheckRepository, certRepo *repository.CertificateRepository, metricRepo *repository.MetricRepository, snmpRepo *repository.SNMPRepository, agentRepo *repository.AgentRepository, hub *events.Hub) *DeviceUsecase {
+	u := &DeviceUsecase{
+		Repo:        repo,
+		TypeMapRepo: typeMapRepo,
+		TypeRepo:    typeRepo,
+		CheckRepo:   checkRepo,
+		CertRepo:    certRepo,
+		MetricRepo:  metricRepo,
+		SNMPRepo:    snmpRepo,
+		AgentRepo:   agentRepo,
+		Probers:     make(map[string]Prober),
+		Tracker:     NewStatusTrackerFromEnv(),
+		Hub:         hub,
+	}
+	for _, p := range DefaultProbers() {
+		u.RegisterProber(p)
 	}
+	return u
+}
+
+// AddListener registers l to be called after every device check.
+func (u *DeviceUsecase) AddListener(l CheckListener) {
+	u.Listeners = append(u.Listeners, l)
+}
+
+// RegisterProber makes a check type available to devices, replacing any
+// prober previously registered for the same type.
+func (u *DeviceUsecase) RegisterProber(p Prober) {
+	u.Probers[p.Type()] = p
 }
 
 func (u *DeviceUsecase) GetAllDevices() ([]domain.Device, error) {
@@ -52,10 +127,17 @@ func (u *DeviceUsecase) InsertDevice(device *domain.Device) error {
 }
 
 func (u *DeviceUsecase) InsertDeviceWithTypes(device *domain.Device, typeIDs []uint) error {
+	if err := u.validateParent(device); err != nil {
+		return err
+	}
 	if err := u.Repo.InsertDevice(device); err != nil {
 		return err
 	}
-	return u.TypeMapRepo.AddDeviceTypes(device.ID, typeIDs)
+	if err := u.TypeMapRepo.AddDeviceTypes(device.ID, typeIDs); err != nil {
+		return err
+	}
+	u.publishDevice(events.TypeDeviceAdded, device.ID)
+	return nil
 }
 
 func (u *DeviceUsecase) UpdateDevice(device *domain.Device) error {
@@ -63,132 +145,430 @@ func (u *DeviceUsecase) UpdateDevice(device *domain.Device) error {
 }
 
 func (u *DeviceUsecase) UpdateDeviceWithTypes(device *domain.Device, typeIDs []uint) error {
+	if err := u.validateParent(device); err != nil {
+		return err
+	}
 	if err := u.Repo.UpdateDevice(device); err != nil {
 		return err
 	}
-	return u.TypeMapRepo.UpdateDeviceTypes(device.ID, typeIDs)
+	if err := u.TypeMapRepo.UpdateDeviceTypes(device.ID, typeIDs); err != nil {
+		return err
+	}
+	u.publishDevice(events.TypeDeviceUpdated, device.ID)
+	return nil
 }
 
-func (u *DeviceUsecase) UpdateDeviceStatus() {
+// validateParent makes sure the parent of device exists and does not
+// depend on device itself.
+func (u *DeviceUsecase) validateParent(device *domain.Device) error {
+	if device.ParentID == nil || *device.ParentID == 0 {
+		return nil
+	}
+	if *device.ParentID == device.ID {
+		return &ValidationError{Msg: "a device cannot be its own parent"}
+	}
 	devices, err := u.Repo.GetAllDevices()
 	if err != nil {
-		log.Printf("Error fetching devices: %v", err)
+		return err
+	}
+	parents := make(map[uint]uint, len(devices))
+	for _, d := range devices {
+		parents[d.ID] = 0
+		if d.ParentID != nil {
+			parents[d.ID] = *d.ParentID
+		}
+	}
+	if _, ok := parents[*device.ParentID]; !ok {
+		return &ValidationError{Msg: fmt.Sprintf("parent device %d does not exist", *device.ParentID)}
+	}
+	for id, n := *device.ParentID, 0; id != 0 && n <= len(parents); id, n = parents[id], n+1 {
+		if id == device.ID {
+			return &ValidationError{Msg: "parent would create a dependency cycle"}
+		}
+	}
+	return nil
+}
+
+// GetDeviceTree returns the dependency tree: every device without a parent
+// with the devices behind it. With rootID set only the tree below that
+// device is returned.
+func (u *DeviceUsecase) GetDeviceTree(rootID uint) ([]domain.DeviceNode, error) {
+	if rootID != 0 {
+		if _, err := u.Repo.GetDeviceByID(rootID); err != nil {
+			return nil, err
+		}
+	}
+	devices, err := u.Repo.GetAllDevices()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].ID < devices[j].ID })
+	exists := make(map[uint]bool, len(devices))
+	for _, d := range devices {
+		exists[d.ID] = true
+	}
+	children := make(map[uint][]domain.Device)
+	for _, d := range devices {
+		parent := uint(0)
+		if d.ParentID != nil && exists[*d.ParentID] {
+			parent = *d.ParentID
+		}
+		children[parent] = append(children[parent], d)
+	}
+	var build func(d domain.Device, seen map[uint]bool) domain.DeviceNode
+	build = func(d domain.Device, seen map[uint]bool) domain.DeviceNode {
+		node := domain.DeviceNode{ID: d.ID, Name: d.Name, Status: d.Status, LocationID: d.LocationID, Children: []domain.DeviceNode{}}
+		seen[d.ID] = true
+		for _, c := range children[d.ID] {
+			if !seen[c.ID] {
+				node.Children = append(node.Children, build(c, seen))
+			}
+		}
+		return node
+	}
+	seen := make(map[uint]bool, len(devices))
+	tree := []domain.DeviceNode{}
+	for _, d := range devices {
+		if (rootID == 0 && (d.ParentID == nil || !exists[*d.ParentID])) || d.ID == rootID {
+			tree = append(tree, build(d, seen))
+		}
+	}
+	return tree, nil
+}
+
+// publishDevice publishes the stored state of a device, with its types, so
+// subscribers can filter on it.
+func (u *DeviceUsecase) publishDevice(eventType string, id uint) {
+	device, err := u.GetDeviceByIDWithTypes(id)
+	if err != nil {
+		log.Printf("Error fetching device %d for event: %v", id, err)
 		return
 	}
+	u.Hub.Publish(events.Event{Type: eventType, DeviceID: id, Device: device})
+}
+
+// CheckReport is the outcome of checking a device once.
+type CheckReport struct {
+	DeviceID uint `json:"device_id"`
+	// Observed is the status derived from this run alone; Status is the
+	// stored status after hysteresis and flap detection.
+	Observed   string         `json:"observed_status"`
+	OldStatus  string         `json:"old_status"`
+	Status     string         `json:"status"`
+	CheckedAt  time.Time      `json:"checked_at"`
+	DurationMs float64        `json:"duration_ms"`
+	Checks     []CheckOutcome `json:"checks"`
+	// AgentsSilent is set when the device is probed by agents and none of
+	// them reported in time.
+	AgentsSilent bool `json:"agents_silent,omitempty"`
+}
+
+// CheckOutcome is one probe result as reported to API clients.
+type CheckOutcome struct {
+	CheckID    uint                   `json:"check_id"`
+	Type       string                 `json:"type"`
+	Status     string                 `json:"status"`
+	LatencyMs  float64                `json:"latency_ms"`
+	LossPct    *float64               `json:"loss_pct,omitempty"`
+	DurationMs float64                `json:"duration_ms"`
+	Error      string                 `json:"error,omitempty"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+}
+
+// CheckDevice runs all checks of a device, passes the derived status
+// through the status tracker, logs a status transition if there is one and
+// stores the new status. It is called by the scheduler for every device
+// that is due. Devices probed remotely use their latest remote results
+// instead of running checks, except for heartbeat checks, which are always
+// evaluated here.
+func (u *DeviceUsecase) CheckDevice(ctx context.Context, device domain.Device) {
+	if _, err := u.checkDevice(ctx, device, false); err != nil {
+		log.Printf("Skipping check of device %s: %v", device.Name, err)
+	}
+}
 
-	for _, device := range devices {
-		// fmt.Println("Checking device:", device.Name)
-		// Check device status using HTTP or ping
-		isOnline := false
+// CheckDeviceNow checks a device right away, outside the schedule. Unlike
+// a scheduled check, the observed status applies at once without waiting
+// for the configured thresholds, so a device that was fixed shows as such.
+// It returns ErrCheckRunning while the device is already being checked and
+// ErrNotLeader on instances that do not probe devices.
+func (u *DeviceUsecase) CheckDeviceNow(ctx context.Context, id uint) (*CheckReport, error) {
+	if !u.leading() {
+		return nil, ErrNotLeader
+	}
+	device, err := u.Repo.GetDeviceByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if u.Guard != nil {
+		if !u.Guard.TryStart(id) {
+			return nil, ErrCheckRunning
+		}
+		defer u.Guard.Done(id)
+	}
+	report, err := u.checkDevice(ctx, *device, true)
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
 
-		if strings.HasPrefix(device.IP, "http") {
-			resp, err := http.Get(device.IP)
-			if err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
-				isOnline = true
-			} else {
-				log.Printf("HTTP request failed for URL %s: %v", device.IP, err)
+// ReceiveHeartbeat records a heartbeat pushed for the check with token. On
+// the leader, a device whose heartbeat check was failing is checked right
+// away so it recovers without waiting for the schedule; other instances
+// leave that to the next scheduled check of the leader.
+func (u *DeviceUsecase) ReceiveHeartbeat(token string) error {
+	check, err := u.CheckRepo.GetCheckByToken(token)
+	if err != nil {
+		return err
+	}
+	if check.Type != domain.CheckTypeHeartbeat || check.Disabled {
+		return &ValidationError{Msg: "check is not an enabled heartbeat check"}
+	}
+	if err := u.CheckRepo.UpdateCheckResult(check.ID, map[string]interface{}{"last_heartbeat_at": time.Now()}); err != nil {
+		return err
+	}
+	if check.LastStatus == domain.StatusOffline && u.leading() {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if _, err := u.CheckDeviceNow(ctx, check.DeviceID); err != nil {
+				log.Printf("Error checking device %d after heartbeat: %v", check.DeviceID, err)
 			}
+		}()
+	}
+	return nil
+}
+
+// leading reports whether this instance probes devices.
+func (u *DeviceUsecase) leading() bool {
+	return u.Leader == nil || u.Leader.IsLeader()
+}
+
+// checkDevice checks device and stores its new status. A manual check
+// applies the observed status at once instead of going through the
+// thresholds of the tracker.
+func (u *DeviceUsecase) checkDevice(ctx context.Context, device domain.Device, manual bool) (CheckReport, error) {
+	start := time.Now()
+	// Nothing is probed when the checks cannot be read, so a database
+	// error does not probe something else instead.
+	checks, err := u.CheckRepo.GetChecksByDevice(device.ID)
+	if err != nil {
+		return CheckReport{}, fmt.Errorf("fetching checks: %w", err)
+	}
+	enabled := EnabledChecks(device, checks)
+	local, delegable := splitHeartbeatChecks(enabled)
+	var results []ProbeResult
+	var observed string
+	remote := false
+	if u.Remote != nil && len(delegable) > 0 {
+		results, observed, remote = u.Remote.RemoteResults(device, start)
+	}
+	silent := remote && observed == domain.StatusUnreachable
+	if !remote {
+		results = u.RunChecks(ctx, device, enabled)
+		observed = DeriveStatus(results)
+	} else if len(local) > 0 {
+		localResults := u.RunChecks(ctx, device, local)
+		results = append(results, localResults...)
+		observed = mergeRemoteStatus(observed, localResults)
+	}
+	tracked := device
+	if tracked.Status == domain.StatusUnreachable {
+		// The tracker knows the device as offline.
+		tracked.Status = domain.StatusOffline
+	}
+	var status string
+	if manual {
+		status = u.Tracker.Override(tracked, observed, time.Now())
+	} else {
+		status = u.Tracker.Observe(tracked, observed, time.Now())
+	}
+	if status == domain.StatusOffline && u.parentDown(device) {
+		status = domain.StatusUnreachable
+	}
+	u.applyStatus(device, status)
+
+	report := CheckReport{
+		DeviceID:     device.ID,
+		Observed:     observed,
+		OldStatus:    device.Status,
+		Status:       status,
+		AgentsSilent: silent,
+		CheckedAt:    start,
+		DurationMs:   ms(time.Since(start)),
+		Checks:       make([]CheckOutcome, 0, len(results)),
+	}
+	for _, res := range results {
+		report.Checks = append(report.Checks, CheckOutcome{
+			CheckID:    res.CheckID,
+			Type:       res.Type,
+			Status:     res.Status,
+			LatencyMs:  ms(res.Latency),
+			LossPct:    res.Loss,
+			DurationMs: ms(res.Duration),
+			Error:      res.Error,
+			Details:    res.Details,
+		})
+	}
+	device.Status = status
+	for _, l := range u.Listeners {
+		l.DeviceChecked(device, report)
+	}
+	return report, nil
+}
+
+// RunChecks runs checks of a device concurrently and stores each check's
+// outcome.
+func (u *DeviceUsecase) RunChecks(ctx context.Context, device domain.Device, checks []domain.Check) []ProbeResult {
+	results := ProbeChecks(ctx, u.Probers, device, checks)
+	u.storeResults(device, results, time.Now())
+	return results
+}
+
+// splitHeartbeatChecks separates the heartbeat checks, which only the
+// server can judge as heartbeats are pushed to it, from the checks agents
+// can run.
+func splitHeartbeatChecks(checks []domain.Check) (heartbeats, others []domain.Check) {
+	for _, check := range checks {
+		if check.Type == domain.CheckTypeHeartbeat {
+			heartbeats = append(heartbeats, check)
 		} else {
-			cmd := exec.Command("ping", "-n", "1", device.IP)
-			if err := cmd.Run(); err == nil {
-				isOnline = true
-			} else {
-				log.Printf("Ping failed for IP %s: %v", device.IP, err)
-			}
+			others = append(others, check)
 		}
+	}
+	return heartbeats, others
+}
 
-		// fmt.Println("Device:", device.Name, "isOnline:", isOnline)
-
-		// Update status and broadcast changes
-		oldStatus := device.Status
-		device.Status = map[bool]string{true: "online", false: "offline"}[isOnline]
-		// fmt.Println("Device:", device.Name, "oldStatus:", oldStatus, "newStatus:", device.Status)
-		if oldStatus != device.Status {
-			log := domain.Log{
-				DeviceID:  device.ID,
-				OldStatus: oldStatus,
-				NewStatus: device.Status,
-				Logtime:   time.Now(),
+// mergeRemoteStatus combines the status the agents of a device agree on
+// with the results of its checks run here, as DeriveStatus would if the
+// agents' status were one more result. A device whose agents are silent
+// stays unreachable.
+func mergeRemoteStatus(remote string, local []ProbeResult) string {
+	if remote == domain.StatusUnreachable {
+		return remote
+	}
+	return DeriveStatus(append([]ProbeResult{{Status: remote}}, local...))
+}
+
+// storeResults records metrics, certificates and the last outcome of each
+// check for results measured at time at.
+func (u *DeviceUsecase) storeResults(device domain.Device, results []ProbeResult, at time.Time) {
+	metrics := make([]domain.DeviceMetric, 0, len(results))
+	for _, res := range results {
+		metrics = append(metrics, metricFromResult(device.ID, res, at))
+		if res.Error != "" {
+			log.Printf("Check %s failed for device %s: %s", res.Type, device.Name, res.Error)
+		}
+		if res.Certificate != nil {
+			if err := u.CertRepo.UpsertCertificate(res.Certificate); err != nil {
+				log.Printf("Error storing certificate for device %s: %v", device.Name, err)
 			}
-			// fmt.Println("Logging status change: ", log)
-			u.Repo.CreateLog(&log)
 		}
-		device.LastOnline = time.Now()
-		// Only update status and lastonline, not other fields
-		err := u.Repo.DB.Model(&domain.Device{}).Where("id = ?", device.ID).Updates(map[string]interface{}{
-			"status":     device.Status,
-			"lastonline": time.Now(),
-		}).Error
+		if res.CheckID == 0 {
+			continue
+		}
+		details, _ := json.Marshal(res.Details)
+		err := u.CheckRepo.UpdateCheckResult(res.CheckID, map[string]interface{}{
+			"last_status":     res.Status,
+			"last_error":      res.Error,
+			"last_latency_ms": ms(res.Latency),
+			"last_details":    details,
+			"last_checked_at": at,
+		})
 		if err != nil {
-			log.Printf("Error updating device %s: %v", device.Name, err)
+			log.Printf("Error updating check %d: %v", res.CheckID, err)
 		}
-		// Trigger broadcast to clients after updating status
-		// u.BroadcastDevices()
-	}
-}
-
-// func (u *DeviceUsecase) BroadcastDevices() {
-// 	fmt.Println("Broadcasting device statuses to clients...")
-// 	devices, err := u.Repo.GetAllDevices()
-// 	// fmt.Println("Fetched devices for broadcast:", devices)
-// 	if err != nil {
-// 		log.Printf("Error fetching devices for broadcast: %v", err)
-// 		return
-// 	}
-
-// 	// Calculate statistics
-// 	total := len(devices)
-// 	online := 0
-// 	for _, device := range devices {
-// 		if device.Status == "online" {
-// 			online++
-// 		}
-// 	}
-// 	offline := total - online
-
-// 	// Broadcast to all clients
-// 	u.BroadcastChannel <- gin.H{
-// 		"total":   total,
-// 		"online":  online,
-// 		"offline": offline,
-// 		"devices": devices,
-// 	}
-// 	fmt.Println("Broadcast completed.")
-// }
-
-// Ensure BroadcastDevices runs in a separate goroutine to avoid blocking
-func (u *DeviceUsecase) BroadcastDevices() {
-	go u.BroadcastDevices()
-}
-
-// func (u *DeviceUsecase) BroadcastDevices() {
-// 	fmt.Println("Broadcasting device statuses to clients...")
-// 	devices, err := u.Repo.GetAllDevices()
-// 	// fmt.Println("Fetched devices for broadcast:", devices)
-// 	if err != nil {
-// 		log.Printf("Error fetching devices for broadcast: %v", err)
-// 		return
-// 	}
-
-// 	// Calculate statistics
-// 	total := len(devices)
-// 	online := 0
-// 	for _, device := range devices {
-// 		if device.Status == "online" {
-// 			online++
-// 		}
-// 	}
-// 	offline := total - online
-
-// 	// Broadcast to all clients
-// 	u.BroadcastChannel <- gin.H{
-// 		"total":   total,
-// 		"online":  online,
-// 		"offline": offline,
-// 		"devices": devices,
-// 	}
-// 	fmt.Println("Broadcast completed.")
-// }
+	}
+	if err := u.MetricRepo.InsertMetrics(metrics); err != nil {
+		log.Printf("Error storing metrics for device %s: %v", device.Name, err)
+	}
+}
+
+func metricFromResult(deviceID uint, res ProbeResult, at time.Time) domain.DeviceMetric {
+	metric := domain.DeviceMetric{
+		DeviceID:   deviceID,
+		CheckID:    res.CheckID,
+		CheckType:  res.Type,
+		Status:     res.Status,
+		LossPct:    res.Loss,
+		DurationMs: ms(res.Duration),
+		MeasuredAt: at,
+	}
+	if res.Status != domain.StatusOffline && res.Latency > 0 {
+		rtt := ms(res.Latency)
+		metric.RTTMs = &rtt
+	}
+	return metric
+}
+
+// parentDown reports whether the parent of device is offline or itself
+// unreachable.
+func (u *DeviceUsecase) parentDown(device domain.Device) bool {
+	if device.ParentID == nil || *device.ParentID == 0 {
+		return false
+	}
+	parent, err := u.Repo.GetDeviceByID(*device.ParentID)
+	if err != nil {
+		return false
+	}
+	return isDown(parent.Status)
+}
+
+// markChildrenUnreachable turns the offline children of a device that went
+// down into unreachable ones, so children checked before their parent do
+// not stay offline.
+func (u *DeviceUsecase) markChildrenUnreachable(parentID uint) {
+	children, err := u.Repo.GetChildren(parentID)
+	if err != nil {
+		log.Printf("Error fetching children of device %d: %v", parentID, err)
+		return
+	}
+	for _, child := range children {
+		if child.Status == domain.StatusOffline {
+			u.applyStatus(child, domain.StatusUnreachable)
+		}
+	}
+}
+
+func (u *DeviceUsecase) applyStatus(device domain.Device, status string) {
+	// Update status and log changes
+	oldStatus := device.Status
+	device.Status = status
+	now := time.Now()
+	if oldStatus != device.Status {
+		log := domain.Log{
+			DeviceID:  device.ID,
+			OldStatus: oldStatus,
+			NewStatus: device.Status,
+			Logtime:   now,
+		}
+		u.Repo.CreateLog(&log)
+	}
+	device.LastOnline = now
+	// Only update status and lastonline, not other fields
+	err := u.Repo.DB.Model(&domain.Device{}).Where("id = ?", device.ID).Updates(map[string]interface{}{
+		"status":     device.Status,
+		"lastonline": now,
+	}).Error
+	if err != nil {
+		log.Printf("Error updating device %s: %v", device.Name, err)
+	}
+	if oldStatus != device.Status {
+		device.Types, _ = u.TypeMapRepo.GetDeviceTypes(device.ID)
+		u.Hub.Publish(events.Event{
+			Type:      events.TypeStatusChanged,
+			DeviceID:  device.ID,
+			Device:    &device,
+			OldStatus: oldStatus,
+			NewStatus: device.Status,
+			Time:      now,
+		})
+		if isDown(device.Status) {
+			u.markChildrenUnreachable(device.ID)
+		}
+	}
+}
 
 func (u *DeviceUsecase) GetDeviceByID(id uint) (*domain.Device, error) {
 	return u.Repo.GetDeviceByID(id)
@@ -205,7 +585,41 @@ func (u *DeviceUsecase) GetDeviceByIDWithTypes(id uint) (*domain.Device, error)
 }
 
 func (u *DeviceUsecase) DeleteDevice(id uint) error {
-	return u.Repo.DeleteDevice(id)
+	// Fetched for the delete event only; subscribers just need the ID.
+	device, err := u.GetDeviceByIDWithTypes(id)
+	if err != nil {
+		device = &domain.Device{ID: id}
+	}
+	if err := u.CheckRepo.DeleteChecksByDevice(id); err != nil {
+		return err
+	}
+	if err := u.CertRepo.DeleteCertificatesByDevice(id); err != nil {
+		return err
+	}
+	if err := u.MetricRepo.DeleteMetricsByDevice(id); err != nil {
+		return err
+	}
+	if err := u.SNMPRepo.DeleteCredential(id); err != nil {
+		return err
+	}
+	if err := u.SNMPRepo.DeleteMetricsByDevice(id); err != nil {
+		return err
+	}
+	if err := u.AgentRepo.DeleteResultsByDevice(id); err != nil {
+		return err
+	}
+	if err := u.Repo.ClearParent(id); err != nil {
+		return err
+	}
+	u.Tracker.Forget(id)
+	if err := u.Repo.DeleteDevice(id); err != nil {
+		return err
+	}
+	if u.Alerts != nil {
+		u.Alerts.ResolveDevice(id)
+	}
+	u.Hub.Publish(events.Event{Type: events.TypeDeviceDeleted, DeviceID: id, Device: device})
+	return nil
 }
 
 func (u *DeviceUsecase) GetDevicesByType(typeID uint) ([]domain.Device, error) {
@@ -0,0 +1,386 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/repository"
+)
+
+// EventEscalated is the event of notifications sent by escalation steps.
+const EventEscalated = "escalated"
+
+// EscalationUsecase manages escalation policies and on-call schedules and
+// walks firing alerts through the policy of their rule. Acknowledged and
+// resolved alerts stop escalating; suppressed ones wait.
+type EscalationUsecase struct {
+	Repo       *repository.EscalationRepository
+	AlertRepo  *repository.AlertRepository
+	Notifier   *NotificationUsecase
+	Suppressor AlertSuppressor // may be nil
+	Tick       time.Duration
+
+	wake chan struct{}
+}
+
+// NewEscalationUsecase looks for due escalation steps every
+// ESCALATION_TICK (default 30s) and whenever an alert starts firing.
+func NewEscalationUsecase(repo *repository.EscalationRepository, alertRepo *repository.AlertRepository, notifier *NotificationUsecase) *EscalationUsecase {
+	tick := 30 * time.Second
+	if v, err := time.ParseDuration(os.Getenv("ESCALATION_TICK")); err == nil && v > 0 {
+		tick = v
+	}
+	return &EscalationUsecase{
+		Repo:      repo,
+		AlertRepo: alertRepo,
+		Notifier:  notifier,
+		Tick:      tick,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+func (u *EscalationUsecase) CreatePolicy(p *domain.EscalationPolicy) error {
+	if err := u.validatePolicy(p); err != nil {
+		return err
+	}
+	return u.Repo.CreatePolicy(p)
+}
+
+func (u *EscalationUsecase) UpdatePolicy(p *domain.EscalationPolicy) error {
+	if err := u.validatePolicy(p); err != nil {
+		return err
+	}
+	return u.Repo.UpdatePolicy(p)
+}
+
+func (u *EscalationUsecase) GetAllPolicies() ([]domain.EscalationPolicy, error) {
+	return u.Repo.GetAllPolicies()
+}
+
+func (u *EscalationUsecase) GetPolicyByID(id uint) (*domain.EscalationPolicy, error) {
+	return u.Repo.GetPolicyByID(id)
+}
+
+// DeletePolicy deletes a policy unless an alert rule uses it.
+func (u *EscalationUsecase) DeletePolicy(id uint) error {
+	rules, err := u.AlertRepo.GetAllRules()
+	if err != nil {
+		return err
+	}
+	for _, r := range rules {
+		if r.EscalationPolicyID == id {
+			return &ValidationError{Msg: fmt.Sprintf("escalation policy is used by alert rule %q", r.Name)}
+		}
+	}
+	return u.Repo.DeletePolicy(id)
+}
+
+// CreateSchedule saves a schedule. Rotations default to a week starting
+// now.
+func (u *EscalationUsecase) CreateSchedule(s *domain.OnCallSchedule) error {
+	if err := u.validateSchedule(s); err != nil {
+		return err
+	}
+	return u.Repo.CreateSchedule(s)
+}
+
+func (u *EscalationUsecase) UpdateSchedule(s *domain.OnCallSchedule) error {
+	if err := u.validateSchedule(s); err != nil {
+		return err
+	}
+	return u.Repo.UpdateSchedule(s)
+}
+
+func (u *EscalationUsecase) GetAllSchedules() ([]domain.OnCallSchedule, error) {
+	return u.Repo.GetAllSchedules()
+}
+
+func (u *EscalationUsecase) GetScheduleByID(id uint) (*domain.OnCallSchedule, error) {
+	return u.Repo.GetScheduleByID(id)
+}
+
+// DeleteSchedule deletes a schedule with its overrides unless an
+// escalation policy notifies it.
+func (u *EscalationUsecase) DeleteSchedule(id uint) error {
+	policy, err := u.policyTargeting(domain.EscalationTarget{Type: domain.EscalationTargetSchedule, ID: id})
+	if err != nil {
+		return err
+	}
+	if policy != "" {
+		return &ValidationError{Msg: fmt.Sprintf("on-call schedule is used by escalation policy %q", policy)}
+	}
+	return u.Repo.DeleteSchedule(id)
+}
+
+func (u *EscalationUsecase) CreateOverride(o *domain.OnCallOverride) error {
+	if _, err := u.Repo.GetScheduleByID(o.ScheduleID); err != nil {
+		return err
+	}
+	if o.Name == "" {
+		return &ValidationError{Msg: "name is required"}
+	}
+	if err := u.validateChannel(o.ChannelID); err != nil {
+		return err
+	}
+	if !o.EndsAt.After(o.StartsAt) {
+		return &ValidationError{Msg: "ends_at must be after starts_at"}
+	}
+	return u.Repo.CreateOverride(o)
+}
+
+// GetOverrides returns the overrides of a schedule that have not ended.
+func (u *EscalationUsecase) GetOverrides(scheduleID uint) ([]domain.OnCallOverride, error) {
+	if _, err := u.Repo.GetScheduleByID(scheduleID); err != nil {
+		return nil, err
+	}
+	return u.Repo.GetOverrides(scheduleID, time.Now())
+}
+
+func (u *EscalationUsecase) DeleteOverride(id uint) error {
+	return u.Repo.DeleteOverride(id)
+}
+
+// GetOnCall returns who is on call for a schedule at time at.
+func (u *EscalationUsecase) GetOnCall(scheduleID uint, at time.Time) (*domain.OnCallParticipant, error) {
+	s, err := u.Repo.GetScheduleByID(scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := u.Repo.GetOverrides(scheduleID, at)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := s.OnCallAt(at, overrides)
+	if !ok {
+		return nil, &ValidationError{Msg: "nobody is on call"}
+	}
+	return &p, nil
+}
+
+// AlertChanged starts escalating new alerts right away instead of at the
+// next tick.
+func (u *EscalationUsecase) AlertChanged(alert domain.Alert) {
+	if alert.State != domain.AlertFiring {
+		return
+	}
+	select {
+	case u.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run escalates alerts every Tick until ctx is cancelled.
+func (u *EscalationUsecase) Run(ctx context.Context) {
+	ticker := time.NewTicker(u.Tick)
+	defer ticker.Stop()
+	for {
+		u.Escalate(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-u.wake:
+		}
+	}
+}
+
+// Escalate takes the due escalation step of every firing alert.
+func (u *EscalationUsecase) Escalate(now time.Time) {
+	alerts, err := u.AlertRepo.GetAlerts(repository.AlertFilter{States: []string{domain.AlertFiring}})
+	if err != nil {
+		log.Printf("Error fetching firing alerts: %v", err)
+		return
+	}
+	if len(alerts) == 0 {
+		return
+	}
+	rules, err := u.AlertRepo.GetAllRules()
+	if err != nil {
+		log.Printf("Error fetching alert rules: %v", err)
+		return
+	}
+	policyOf := make(map[uint]uint, len(rules))
+	for _, r := range rules {
+		policyOf[r.ID] = r.EscalationPolicyID
+	}
+	policies, err := u.Repo.GetAllPolicies()
+	if err != nil {
+		log.Printf("Error fetching escalation policies: %v", err)
+		return
+	}
+	byID := make(map[uint]domain.EscalationPolicy, len(policies))
+	for _, p := range policies {
+		byID[p.ID] = p
+	}
+
+	for _, alert := range alerts {
+		policy, ok := byID[policyOf[alert.RuleID]]
+		if !ok || len(policy.Steps) == 0 || alert.EscalationStep >= len(policy.Steps)*(policy.Repeat+1) {
+			continue
+		}
+		step := policy.Steps[alert.EscalationStep%len(policy.Steps)]
+		last := alert.StartedAt
+		if alert.EscalatedAt != nil {
+			last = *alert.EscalatedAt
+		}
+		if now.Before(last.Add(time.Duration(step.DelayMinutes) * time.Minute)) {
+			continue
+		}
+		if u.Suppressor != nil && u.Suppressor.SuppressAlert(alert, now) {
+			continue
+		}
+		// Record the step first so failing targets do not repeat it.
+		if err := u.AlertRepo.UpdateAlert(alert.ID, map[string]interface{}{
+			"escalation_step": alert.EscalationStep + 1,
+			"escalated_at":    now,
+		}); err != nil {
+			log.Printf("Error updating escalation of alert %d: %v", alert.ID, err)
+			continue
+		}
+		alert.EscalationStep++
+		alert.EscalatedAt = &now
+		log.Printf("Escalating alert %d, step %d of policy %s", alert.ID, alert.EscalationStep, policy.Name)
+		for _, target := range step.Targets {
+			if err := u.notify(target, alert, now); err != nil {
+				log.Printf("Error escalating alert %d to %s %d: %v", alert.ID, target.Type, target.ID, err)
+			}
+		}
+	}
+}
+
+func (u *EscalationUsecase) notify(target domain.EscalationTarget, alert domain.Alert, now time.Time) error {
+	channelID := target.ID
+	if target.Type == domain.EscalationTargetSchedule {
+		p, err := u.GetOnCall(target.ID, now)
+		if err != nil {
+			return err
+		}
+		channelID = p.ChannelID
+	}
+	return u.Notifier.NotifyChannel(channelID, alert, EventEscalated)
+}
+
+// ChannelUsedBy implements ChannelUser for the policies, schedules and
+// current or future overrides that notify a channel.
+func (u *EscalationUsecase) ChannelUsedBy(channelID uint) (string, error) {
+	policy, err := u.policyTargeting(domain.EscalationTarget{Type: domain.EscalationTargetChannel, ID: channelID})
+	if err != nil {
+		return "", err
+	}
+	if policy != "" {
+		return fmt.Sprintf("escalation policy %q", policy), nil
+	}
+	schedules, err := u.Repo.GetAllSchedules()
+	if err != nil {
+		return "", err
+	}
+	for _, s := range schedules {
+		for _, p := range s.Participants {
+			if p.ChannelID == channelID {
+				return fmt.Sprintf("on-call schedule %q", s.Name), nil
+			}
+		}
+	}
+	overrides, err := u.Repo.GetOverridesByChannel(channelID, time.Now())
+	if err != nil {
+		return "", err
+	}
+	if len(overrides) > 0 {
+		return fmt.Sprintf("on-call override %q", overrides[0].Name), nil
+	}
+	return "", nil
+}
+
+// policyTargeting returns the name of a policy with a step notifying
+// target, or "" when there is none.
+func (u *EscalationUsecase) policyTargeting(target domain.EscalationTarget) (string, error) {
+	policies, err := u.Repo.GetAllPolicies()
+	if err != nil {
+		return "", err
+	}
+	for _, p := range policies {
+		for _, step := range p.Steps {
+			for _, t := range step.Targets {
+				if t == target {
+					return p.Name, nil
+				}
+			}
+		}
+	}
+	return "", nil
+}
+
+func (u *EscalationUsecase) validatePolicy(p *domain.EscalationPolicy) error {
+	if p.Name == "" {
+		return &ValidationError{Msg: "name is required"}
+	}
+	if len(p.Steps) == 0 {
+		return &ValidationError{Msg: "at least one step is required"}
+	}
+	if p.Repeat < 0 {
+		return &ValidationError{Msg: "repeat must not be negative"}
+	}
+	for i, step := range p.Steps {
+		if step.DelayMinutes < 0 {
+			return &ValidationError{Msg: fmt.Sprintf("step %d: delay_minutes must not be negative", i+1)}
+		}
+		if len(step.Targets) == 0 {
+			return &ValidationError{Msg: fmt.Sprintf("step %d: at least one target is required", i+1)}
+		}
+		for _, t := range step.Targets {
+			var err error
+			switch t.Type {
+			case domain.EscalationTargetChannel:
+				err = u.validateChannel(t.ID)
+			case domain.EscalationTargetSchedule:
+				if _, e := u.Repo.GetScheduleByID(t.ID); e != nil {
+					err = &ValidationError{Msg: fmt.Sprintf("schedule %d does not exist", t.ID)}
+				}
+			default:
+				err = &ValidationError{Msg: fmt.Sprintf("unknown target type %q", t.Type)}
+			}
+			if err != nil {
+				return &ValidationError{Msg: fmt.Sprintf("step %d: %v", i+1, err)}
+			}
+		}
+	}
+	return nil
+}
+
+func (u *EscalationUsecase) validateSchedule(s *domain.OnCallSchedule) error {
+	if s.Name == "" {
+		return &ValidationError{Msg: "name is required"}
+	}
+	if s.RotationHours == 0 {
+		s.RotationHours = 7 * 24
+	}
+	if s.RotationHours < 0 {
+		return &ValidationError{Msg: "rotation_hours must be positive"}
+	}
+	if s.RotationStart.IsZero() {
+		s.RotationStart = time.Now()
+	}
+	if len(s.Participants) == 0 {
+		return &ValidationError{Msg: "at least one participant is required"}
+	}
+	for _, p := range s.Participants {
+		if p.Name == "" {
+			return &ValidationError{Msg: "participants need a name"}
+		}
+		if err := u.validateChannel(p.ChannelID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *EscalationUsecase) validateChannel(id uint) error {
+	if _, err := u.Notifier.GetChannelByID(id); err != nil {
+		return &ValidationError{Msg: fmt.Sprintf("notification channel %d does not exist", id)}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/repository"
+)
+
+const (
+	defaultLogPageSize = 50
+	maxLogPageSize     = 500
+)
+
+type LogUsecase struct {
+	Repo *repository.LogRepository
+}
+
+func NewLogUsecase(repo *repository.LogRepository) *LogUsecase {
+	return &LogUsecase{Repo: repo}
+}
+
+// GetLogs returns a page of status transitions. Missing or out of range
+// paging values in f are replaced by their defaults.
+func (u *LogUsecase) GetLogs(f *repository.LogFilter) ([]domain.Log, int64, error) {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PageSize < 1 {
+		f.PageSize = defaultLogPageSize
+	}
+	if f.PageSize > maxLogPageSize {
+		f.PageSize = maxLogPageSize
+	}
+	return u.Repo.GetLogs(*f)
+}
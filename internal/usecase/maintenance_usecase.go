@@ -0,0 +1,372 @@
+package usecase
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/repository"
+)
+
+const (
+	// maintenanceCacheTTL is how long windows and silences are cached
+	// before they are read again, so changes made elsewhere are seen.
+	maintenanceCacheTTL = 30 * time.Second
+	// maxOccurrences caps the occurrences of a recurring window computed
+	// for one period of time.
+	maxOccurrences = 100000
+)
+
+// MaintenanceUsecase manages maintenance windows and silences and tells
+// the alert engine and reports when they apply.
+type MaintenanceUsecase struct {
+	Repo        *repository.MaintenanceRepository
+	TypeMapRepo *repository.DeviceTypeMapRepository
+
+	mu       sync.Mutex
+	loadedAt time.Time
+	windows  []maintenanceWindow
+	silences []domain.Silence
+}
+
+// maintenanceWindow is a window with its schedule parsed.
+type maintenanceWindow struct {
+	domain.MaintenanceWindow
+	schedule cron.Schedule // nil for one-off windows
+}
+
+func NewMaintenanceUsecase(repo *repository.MaintenanceRepository, typeMapRepo *repository.DeviceTypeMapRepository) *MaintenanceUsecase {
+	return &MaintenanceUsecase{Repo: repo, TypeMapRepo: typeMapRepo}
+}
+
+func (u *MaintenanceUsecase) CreateWindow(w *domain.MaintenanceWindow) error {
+	if _, err := parseWindow(*w); err != nil {
+		return err
+	}
+	if err := u.Repo.CreateWindow(w); err != nil {
+		return err
+	}
+	u.invalidate()
+	return nil
+}
+
+func (u *MaintenanceUsecase) UpdateWindow(w *domain.MaintenanceWindow) error {
+	if _, err := parseWindow(*w); err != nil {
+		return err
+	}
+	if err := u.Repo.UpdateWindow(w); err != nil {
+		return err
+	}
+	u.invalidate()
+	return nil
+}
+
+// GetAllWindows returns all windows with Active set for those in effect
+// now.
+func (u *MaintenanceUsecase) GetAllWindows() ([]domain.MaintenanceWindow, error) {
+	windows, err := u.Repo.GetAllWindows()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range windows {
+		if w, err := parseWindow(windows[i]); err == nil {
+			windows[i].Active = w.activeAt(now)
+		}
+	}
+	return windows, nil
+}
+
+func (u *MaintenanceUsecase) GetWindowByID(id uint) (*domain.MaintenanceWindow, error) {
+	window, err := u.Repo.GetWindowByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if w, err := parseWindow(*window); err == nil {
+		window.Active = w.activeAt(time.Now())
+	}
+	return window, nil
+}
+
+func (u *MaintenanceUsecase) DeleteWindow(id uint) error {
+	if err := u.Repo.DeleteWindow(id); err != nil {
+		return err
+	}
+	u.invalidate()
+	return nil
+}
+
+// GetWindowOccurrences returns when a window is in effect within
+// [from, to).
+func (u *MaintenanceUsecase) GetWindowOccurrences(id uint, from, to time.Time) ([]domain.Period, error) {
+	window, err := u.Repo.GetWindowByID(id)
+	if err != nil {
+		return nil, err
+	}
+	w, err := parseWindow(*window)
+	if err != nil {
+		return nil, err
+	}
+	return w.periods(from, to), nil
+}
+
+// CreateSilence saves a silence. It starts now unless StartsAt says
+// otherwise.
+func (u *MaintenanceUsecase) CreateSilence(s *domain.Silence) error {
+	if s.Reason == "" {
+		return &ValidationError{Msg: "reason is required"}
+	}
+	if len(s.RuleIDs) == 0 && len(s.DeviceIDs) == 0 && len(s.LocationIDs) == 0 {
+		return &ValidationError{Msg: "rule_ids, device_ids or location_ids is required"}
+	}
+	if s.StartsAt.IsZero() {
+		s.StartsAt = time.Now()
+	}
+	if s.ExpiresAt.IsZero() {
+		return &ValidationError{Msg: "expires_at is required"}
+	}
+	if !s.ExpiresAt.After(s.StartsAt) || !s.ExpiresAt.After(time.Now()) {
+		return &ValidationError{Msg: "expires_at must be in the future and after starts_at"}
+	}
+	if err := u.Repo.CreateSilence(s); err != nil {
+		return err
+	}
+	u.invalidate()
+	return nil
+}
+
+// GetSilences returns all silences, or with unexpiredOnly only those that
+// have not expired yet.
+func (u *MaintenanceUsecase) GetSilences(unexpiredOnly bool) ([]domain.Silence, error) {
+	if unexpiredOnly {
+		now := time.Now()
+		return u.Repo.GetSilences(&now)
+	}
+	return u.Repo.GetSilences(nil)
+}
+
+func (u *MaintenanceUsecase) GetSilenceByID(id uint) (*domain.Silence, error) {
+	return u.Repo.GetSilenceByID(id)
+}
+
+// ExpireSilence ends a silence now.
+func (u *MaintenanceUsecase) ExpireSilence(id uint) error {
+	s, err := u.Repo.GetSilenceByID(id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if !s.ExpiresAt.After(now) {
+		return &ValidationError{Msg: "silence has already expired"}
+	}
+	if err := u.Repo.ExpireSilence(id, now); err != nil {
+		return err
+	}
+	u.invalidate()
+	return nil
+}
+
+func (u *MaintenanceUsecase) DeleteSilence(id uint) error {
+	if err := u.Repo.DeleteSilence(id); err != nil {
+		return err
+	}
+	u.invalidate()
+	return nil
+}
+
+// SuppressAlert reports whether alert is muted by a silence or falls in a
+// maintenance window at time at. Location alerts are only covered by
+// windows scoped to their location.
+func (u *MaintenanceUsecase) SuppressAlert(alert domain.Alert, at time.Time) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err := u.load(at); err != nil {
+		log.Printf("Error loading maintenance windows: %v", err)
+		return false
+	}
+	for _, s := range u.silences {
+		if s.ActiveAt(at) && s.Mutes(alert) {
+			return true
+		}
+	}
+	var typeIDs []uint
+	typesLoaded := false
+	for _, w := range u.windows {
+		if !w.activeAt(at) {
+			continue
+		}
+		if alert.DeviceID == 0 {
+			if slices.Contains(w.LocationIDs, alert.LocationID) {
+				return true
+			}
+			continue
+		}
+		if len(w.TypeIDs) > 0 && !typesLoaded {
+			typeIDs = u.deviceTypeIDs(alert.DeviceID)
+			typesLoaded = true
+		}
+		if w.Covers(domain.Device{ID: alert.DeviceID, LocationID: alert.LocationID}, typeIDs) {
+			return true
+		}
+	}
+	return false
+}
+
+// DevicePeriods returns the merged maintenance periods of a device with
+// the given types within [from, to).
+func (u *MaintenanceUsecase) DevicePeriods(device domain.Device, typeIDs []uint, from, to time.Time) ([]domain.Period, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err := u.load(time.Now()); err != nil {
+		return nil, err
+	}
+	var periods []domain.Period
+	for _, w := range u.windows {
+		if w.Covers(device, typeIDs) {
+			periods = append(periods, w.periods(from, to)...)
+		}
+	}
+	return mergePeriods(periods), nil
+}
+
+func (u *MaintenanceUsecase) deviceTypeIDs(deviceID uint) []uint {
+	types, err := u.TypeMapRepo.GetDeviceTypes(deviceID)
+	if err != nil {
+		log.Printf("Error fetching types of device %d: %v", deviceID, err)
+	}
+	ids := make([]uint, 0, len(types))
+	for _, t := range types {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+// load refreshes the cache when it is older than maintenanceCacheTTL.
+// Callers hold u.mu.
+func (u *MaintenanceUsecase) load(now time.Time) error {
+	if !u.loadedAt.IsZero() && now.Sub(u.loadedAt) < maintenanceCacheTTL {
+		return nil
+	}
+	windows, err := u.Repo.GetAllWindows()
+	if err != nil {
+		return err
+	}
+	silences, err := u.Repo.GetSilences(&now)
+	if err != nil {
+		return err
+	}
+	u.windows = u.windows[:0]
+	for _, window := range windows {
+		w, err := parseWindow(window)
+		if err != nil {
+			log.Printf("Skipping maintenance window %d: %v", window.ID, err)
+			continue
+		}
+		if !w.Disabled {
+			u.windows = append(u.windows, w)
+		}
+	}
+	u.silences = silences
+	u.loadedAt = now
+	return nil
+}
+
+func (u *MaintenanceUsecase) invalidate() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.loadedAt = time.Time{}
+}
+
+func parseWindow(w domain.MaintenanceWindow) (maintenanceWindow, error) {
+	parsed := maintenanceWindow{MaintenanceWindow: w}
+	if w.Name == "" {
+		return parsed, &ValidationError{Msg: "name is required"}
+	}
+	if len(w.DeviceIDs) == 0 && len(w.TypeIDs) == 0 && len(w.LocationIDs) == 0 {
+		return parsed, &ValidationError{Msg: "device_ids, type_ids or location_ids is required"}
+	}
+	if w.StartsAt != nil && w.EndsAt != nil && !w.EndsAt.After(*w.StartsAt) {
+		return parsed, &ValidationError{Msg: "ends_at must be after starts_at"}
+	}
+	if w.Schedule == "" {
+		if w.StartsAt == nil || w.EndsAt == nil {
+			return parsed, &ValidationError{Msg: "starts_at and ends_at are required without a schedule"}
+		}
+		return parsed, nil
+	}
+	schedule, err := cron.ParseStandard(w.Schedule)
+	if err != nil {
+		return parsed, &ValidationError{Msg: fmt.Sprintf("invalid schedule: %v", err)}
+	}
+	if w.DurationMinutes <= 0 {
+		return parsed, &ValidationError{Msg: "duration_minutes must be positive for a recurring window"}
+	}
+	parsed.schedule = schedule
+	return parsed, nil
+}
+
+// periods returns the occurrences of the window overlapping [from, to),
+// clipped to it.
+func (w maintenanceWindow) periods(from, to time.Time) []domain.Period {
+	if w.Disabled {
+		return nil
+	}
+	if w.StartsAt != nil && w.StartsAt.After(from) {
+		from = *w.StartsAt
+	}
+	if w.EndsAt != nil && w.EndsAt.Before(to) {
+		to = *w.EndsAt
+	}
+	if !from.Before(to) {
+		return nil
+	}
+	if w.schedule == nil {
+		return []domain.Period{{Start: from, End: to}}
+	}
+	d := time.Duration(w.DurationMinutes) * time.Minute
+	var periods []domain.Period
+	// Next is strictly after its argument; start just before the earliest
+	// occurrence that can still reach from.
+	start := w.schedule.Next(from.Add(-d).Add(-time.Nanosecond))
+	for n := 0; !start.IsZero() && start.Before(to) && n < maxOccurrences; n++ {
+		p := domain.Period{Start: start, End: start.Add(d)}
+		if p.Start.Before(from) {
+			p.Start = from
+		}
+		if p.End.After(to) {
+			p.End = to
+		}
+		if p.Start.Before(p.End) {
+			periods = append(periods, p)
+		}
+		start = w.schedule.Next(start)
+	}
+	return mergePeriods(periods)
+}
+
+func (w maintenanceWindow) activeAt(t time.Time) bool {
+	return len(w.periods(t, t.Add(time.Nanosecond))) > 0
+}
+
+// mergePeriods sorts periods and merges those that overlap or touch.
+func mergePeriods(periods []domain.Period) []domain.Period {
+	if len(periods) < 2 {
+		return periods
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Start.Before(periods[j].Start) })
+	merged := periods[:1]
+	for _, p := range periods[1:] {
+		last := &merged[len(merged)-1]
+		if p.Start.After(last.End) {
+			merged = append(merged, p)
+		} else if p.End.After(last.End) {
+			last.End = p.End
+		}
+	}
+	return merged
+}
@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"os"
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/repository"
+)
+
+// maxMetricPoints caps how many buckets one query may return.
+const maxMetricPoints = 2000
+
+type MetricUsecase struct {
+	Repo      *repository.MetricRepository
+	Retention time.Duration
+}
+
+// NewMetricUsecase keeps metrics for METRICS_RETENTION (default 30 days).
+func NewMetricUsecase(repo *repository.MetricRepository) *MetricUsecase {
+	retention := 30 * 24 * time.Hour
+	if v, err := time.ParseDuration(os.Getenv("METRICS_RETENTION")); err == nil && v > 0 {
+		retention = v
+	}
+	return &MetricUsecase{Repo: repo, Retention: retention}
+}
+
+// GetDeviceMetrics aggregates the metrics of a device into buckets of
+// step. Without a step, or when step would produce too many points, the
+// range is split into roughly 300 buckets. The step used is returned with
+// the points.
+func (u *MetricUsecase) GetDeviceMetrics(deviceID uint, from, to time.Time, step time.Duration, filter repository.MetricFilter) ([]domain.MetricPoint, time.Duration, error) {
+	span := to.Sub(from)
+	if step <= 0 {
+		step = span / 300
+	}
+	// Steps are whole seconds, which also keeps short ranges from dividing
+	// by zero below.
+	step = max(step.Truncate(time.Second), time.Second)
+	if span/step > maxMetricPoints {
+		step = (span / maxMetricPoints).Truncate(time.Second)
+	}
+	points, err := u.Repo.GetAggregatedMetrics(deviceID, from, to, step, filter)
+	return points, step, err
+}
+
+// PruneMetrics deletes metrics older than the retention period.
+func (u *MetricUsecase) PruneMetrics() (int64, error) {
+	return u.Repo.DeleteMetricsBefore(time.Now().Add(-u.Retention))
+}
@@ -0,0 +1,217 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/notify"
+	"github.com/simonaditiabbp/netmon-backend/internal/repository"
+)
+
+// NotificationUsecase manages notification channels and sends alert
+// changes to them. Deliveries run in the background, are retried with
+// backoff and are recorded with their outcome.
+type NotificationUsecase struct {
+	Repo    *repository.NotificationRepository
+	Retry   notify.Retry
+	Timeout time.Duration // for one delivery including its retries
+	InUse   ChannelUser   // may be nil
+}
+
+// ChannelUser refers to notification channels by ID. Channels it refers
+// to cannot be deleted.
+type ChannelUser interface {
+	// ChannelUsedBy names what refers to a channel, or returns "" when
+	// nothing does.
+	ChannelUsedBy(channelID uint) (string, error)
+}
+
+// NewNotificationUsecase makes NOTIFY_ATTEMPTS (default 4) attempts per
+// delivery, waiting NOTIFY_BACKOFF (default 2s, doubled every time) in
+// between.
+func NewNotificationUsecase(repo *repository.NotificationRepository) *NotificationUsecase {
+	retry := notify.Retry{Attempts: 4, Backoff: 2 * time.Second}
+	if v, err := strconv.Atoi(os.Getenv("NOTIFY_ATTEMPTS")); err == nil && v > 0 {
+		retry.Attempts = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("NOTIFY_BACKOFF")); err == nil && v > 0 {
+		retry.Backoff = v
+	}
+	return &NotificationUsecase{Repo: repo, Retry: retry, Timeout: 5 * time.Minute}
+}
+
+func (u *NotificationUsecase) CreateChannel(ch *domain.NotificationChannel) error {
+	if err := validateChannel(ch); err != nil {
+		return err
+	}
+	return u.Repo.CreateChannel(ch)
+}
+
+// UpdateChannel saves ch. Secrets left out of its params, as they are in
+// API responses, keep their stored values.
+func (u *NotificationUsecase) UpdateChannel(ch *domain.NotificationChannel) error {
+	old, err := u.Repo.GetChannelByID(ch.ID)
+	if err != nil {
+		return err
+	}
+	ch.KeepSecrets(*old)
+	if err := validateChannel(ch); err != nil {
+		return err
+	}
+	return u.Repo.UpdateChannel(ch)
+}
+
+func (u *NotificationUsecase) GetAllChannels() ([]domain.NotificationChannel, error) {
+	return u.Repo.GetAllChannels()
+}
+
+func (u *NotificationUsecase) GetChannelByID(id uint) (*domain.NotificationChannel, error) {
+	return u.Repo.GetChannelByID(id)
+}
+
+// DeleteChannel deletes a channel unless something still sends to it.
+func (u *NotificationUsecase) DeleteChannel(id uint) error {
+	if u.InUse != nil {
+		by, err := u.InUse.ChannelUsedBy(id)
+		if err != nil {
+			return err
+		}
+		if by != "" {
+			return &ValidationError{Msg: fmt.Sprintf("notification channel is used by %s", by)}
+		}
+	}
+	return u.Repo.DeleteChannel(id)
+}
+
+func (u *NotificationUsecase) GetDeliveries(alertID, channelID uint, limit int) ([]domain.NotificationDelivery, error) {
+	return u.Repo.GetDeliveries(alertID, channelID, limit)
+}
+
+// AlertChanged sends an alert change to every enabled channel that wants
+// it. It returns right away; deliveries happen in the background.
+func (u *NotificationUsecase) AlertChanged(alert domain.Alert) {
+	go func() {
+		channels, err := u.Repo.GetAllChannels()
+		if err != nil {
+			log.Printf("Error fetching notification channels: %v", err)
+			return
+		}
+		for _, ch := range channels {
+			if wantsAlert(ch, alert) {
+				go u.deliver(ch, alert, alert.State)
+			}
+		}
+	}()
+}
+
+// NotifyChannel sends an alert to one channel in the background, whatever
+// severities and events the channel is set up for. Disabled channels are
+// skipped.
+func (u *NotificationUsecase) NotifyChannel(channelID uint, alert domain.Alert, event string) error {
+	ch, err := u.Repo.GetChannelByID(channelID)
+	if err != nil {
+		return err
+	}
+	if !ch.Disabled {
+		go u.deliver(*ch, alert, event)
+	}
+	return nil
+}
+
+// TestChannel sends a sample alert to a channel once, without retries,
+// and returns the error of the attempt.
+func (u *NotificationUsecase) TestChannel(ctx context.Context, id uint) (*domain.NotificationDelivery, error) {
+	ch, err := u.Repo.GetChannelByID(id)
+	if err != nil {
+		return nil, err
+	}
+	alert := domain.Alert{
+		Severity:  domain.SeverityInfo,
+		State:     domain.AlertFiring,
+		Message:   fmt.Sprintf("Test notification for channel %s", ch.Name),
+		StartedAt: time.Now(),
+		RuleName:  "Test",
+	}
+	return u.send(ctx, *ch, alert, "test", notify.Retry{Attempts: 1}), nil
+}
+
+func (u *NotificationUsecase) deliver(ch domain.NotificationChannel, alert domain.Alert, event string) {
+	ctx, cancel := context.WithTimeout(context.Background(), u.Timeout)
+	defer cancel()
+	d := u.send(ctx, ch, alert, event, u.Retry)
+	if d.Status == domain.DeliveryFailed {
+		log.Printf("Error notifying channel %s of alert %d after %d attempts: %s", ch.Name, alert.ID, d.Attempts, d.Error)
+	}
+}
+
+// send renders and sends one message and records the delivery.
+func (u *NotificationUsecase) send(ctx context.Context, ch domain.NotificationChannel, alert domain.Alert, event string, retry notify.Retry) *domain.NotificationDelivery {
+	d := &domain.NotificationDelivery{ChannelID: ch.ID, AlertID: alert.ID, Event: event, Status: domain.DeliverySent}
+	err := func() error {
+		n, err := notify.New(ch.Type, ch.Params)
+		if err != nil {
+			return err
+		}
+		tmpl, err := notify.ParseTemplate(ch.SubjectTemplate, ch.BodyTemplate)
+		if err != nil {
+			return err
+		}
+		msg, err := tmpl.Render(event, alert)
+		if err != nil {
+			return err
+		}
+		d.Attempts, err = notify.Deliver(ctx, n, msg, retry)
+		return err
+	}()
+	if err != nil {
+		d.Status = domain.DeliveryFailed
+		d.Error = err.Error()
+	}
+	if err := u.Repo.CreateDelivery(d); err != nil {
+		log.Printf("Error recording notification delivery: %v", err)
+	}
+	return d
+}
+
+func wantsAlert(ch domain.NotificationChannel, alert domain.Alert) bool {
+	if ch.Disabled || domain.SeverityRank(alert.Severity) < domain.SeverityRank(ch.MinSeverity) {
+		return false
+	}
+	if len(ch.Events) == 0 {
+		return true
+	}
+	for _, e := range ch.Events {
+		if e == alert.State {
+			return true
+		}
+	}
+	return false
+}
+
+func validateChannel(ch *domain.NotificationChannel) error {
+	if ch.Name == "" {
+		return &ValidationError{Msg: "name is required"}
+	}
+	if _, err := notify.New(ch.Type, ch.Params); err != nil {
+		return &ValidationError{Msg: err.Error()}
+	}
+	if _, err := notify.ParseTemplate(ch.SubjectTemplate, ch.BodyTemplate); err != nil {
+		return &ValidationError{Msg: err.Error()}
+	}
+	if ch.MinSeverity != "" && domain.SeverityRank(ch.MinSeverity) == 0 {
+		return &ValidationError{Msg: fmt.Sprintf("unknown severity %q", ch.MinSeverity)}
+	}
+	for _, e := range ch.Events {
+		switch e {
+		case domain.AlertFiring, domain.AlertAcknowledged, domain.AlertResolved:
+		default:
+			return &ValidationError{Msg: fmt.Sprintf("unknown event %q", e)}
+		}
+	}
+	return nil
+}
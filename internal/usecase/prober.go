@@ -0,0 +1,176 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/ping"
+)
+
+// Prober runs one type of check against a device. Implementations must
+// return once ctx is done and report failures through the result instead
+// of an error.
+type Prober interface {
+	Type() string
+	Probe(ctx context.Context, device domain.Device, check domain.Check) ProbeResult
+}
+
+// ParamsValidator is implemented by probers that can reject invalid params
+// before a check is saved, instead of failing on every run.
+type ParamsValidator interface {
+	ValidateParams(check domain.Check) error
+}
+
+type ProbeResult struct {
+	CheckID uint          `json:"check_id"`
+	Type    string        `json:"type"`
+	Status  string        `json:"status"`
+	Latency time.Duration `json:"latency"`
+	// Loss is the packet loss in percent, for checks that measure it.
+	Loss *float64 `json:"loss,omitempty"`
+	// Duration is how long the probe took, set by the device usecase.
+	Duration time.Duration          `json:"duration"`
+	Error    string                 `json:"error,omitempty"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+	// Certificate is set by checks that completed a TLS handshake.
+	Certificate *domain.Certificate `json:"certificate,omitempty"`
+}
+
+// DefaultProbers returns a prober for every built-in check type.
+func DefaultProbers() []Prober {
+	return []Prober{
+		NewICMPProber(ping.NewPingerFromEnv()),
+		NewHTTPProber(),
+		NewTCPProber(),
+		NewTLSProber(),
+		NewDNSProber(),
+		NewHeartbeatProber(),
+	}
+}
+
+// ProbeChecks runs checks against device concurrently, using the prober
+// registered for each check's type.
+func ProbeChecks(ctx context.Context, probers map[string]Prober, device domain.Device, checks []domain.Check) []ProbeResult {
+	results := make([]ProbeResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			prober, ok := probers[check.Type]
+			if !ok {
+				results[i] = failedResult(check, fmt.Errorf("unknown check type %q", check.Type))
+				return
+			}
+			start := time.Now()
+			results[i] = prober.Probe(ctx, device, check)
+			results[i].Duration = time.Since(start)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// EnabledChecks returns the checks of device that are not disabled, or its
+// implicit checks when there are none.
+func EnabledChecks(device domain.Device, checks []domain.Check) []domain.Check {
+	enabled := make([]domain.Check, 0, len(checks))
+	for _, check := range checks {
+		if !check.Disabled {
+			enabled = append(enabled, check)
+		}
+	}
+	if len(enabled) == 0 {
+		enabled = implicitChecks(device)
+	}
+	return enabled
+}
+
+func failedResult(check domain.Check, err error) ProbeResult {
+	return ProbeResult{
+		CheckID: check.ID,
+		Type:    check.Type,
+		Status:  domain.StatusOffline,
+		Error:   err.Error(),
+	}
+}
+
+// DeriveStatus combines the results of all checks of a device: offline
+// when every check failed, degraded when only some did, warning when a
+// check warns, online otherwise.
+func DeriveStatus(results []ProbeResult) string {
+	counts := make(map[string]int)
+	for _, r := range results {
+		counts[r.Status]++
+	}
+	switch {
+	case len(results) == 0 || counts[domain.StatusOffline] == len(results):
+		return domain.StatusOffline
+	case counts[domain.StatusOffline] > 0 || counts[domain.StatusDegraded] > 0:
+		return domain.StatusDegraded
+	case counts[domain.StatusWarning] > 0:
+		return domain.StatusWarning
+	}
+	return domain.StatusOnline
+}
+
+// isDown reports whether a status counts as downtime. Unreachable devices
+// are down too, even if the cause lies elsewhere.
+func isDown(status string) bool {
+	return status == domain.StatusOffline || status == domain.StatusUnreachable
+}
+
+// implicitChecks keeps devices without configured checks working as they
+// did before checks existed: one HTTP check when the device has a URL (or
+// still stores one in IP), otherwise a ping when it has an IP.
+func implicitChecks(device domain.Device) []domain.Check {
+	switch {
+	case deviceURL(device, "") != "":
+		return []domain.Check{{DeviceID: device.ID, Type: domain.CheckTypeHTTP}}
+	case device.IP != "":
+		return []domain.Check{{DeviceID: device.ID, Type: domain.CheckTypeICMP}}
+	}
+	return nil
+}
+
+func decodeParams(check domain.Check, v interface{}) error {
+	if len(check.Params) == 0 || string(check.Params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(check.Params, v); err != nil {
+		return fmt.Errorf("invalid %s params: %w", check.Type, err)
+	}
+	return nil
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// duration accepts either a Go duration string ("1.5s") or a number of
+// milliseconds in check params.
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case float64:
+		*d = duration(time.Duration(v * float64(time.Millisecond)))
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*d = duration(parsed)
+	default:
+		return fmt.Errorf("invalid duration %s", b)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+)
+
+type DNSParams struct {
+	Name string `json:"name"`
+	// Resolver is the DNS server to ask, e.g. "1.1.1.1" or "10.0.0.53:53".
+	// The system resolver is used when empty.
+	Resolver string `json:"resolver"`
+	// Records maps a record type (A, AAAA, CNAME, MX, NS, TXT) to the
+	// expected answers. An empty list only requires the lookup to succeed.
+	// Answers are compared case-insensitively and MX answers by host name
+	// only.
+	Records map[string][]string `json:"records"`
+	Timeout duration            `json:"timeout"`
+}
+
+type DNSProber struct {
+	Timeout time.Duration
+}
+
+func NewDNSProber() *DNSProber {
+	return &DNSProber{Timeout: 5 * time.Second}
+}
+
+func (p *DNSProber) Type() string {
+	return domain.CheckTypeDNS
+}
+
+func (p *DNSProber) ValidateParams(check domain.Check) error {
+	var params DNSParams
+	if err := decodeParams(check, &params); err != nil {
+		return err
+	}
+	for rtype := range params.Records {
+		switch strings.ToUpper(rtype) {
+		case "A", "AAAA", "CNAME", "MX", "NS", "TXT":
+		default:
+			return fmt.Errorf("unsupported record type %q", rtype)
+		}
+	}
+	return nil
+}
+
+func (p *DNSProber) Probe(ctx context.Context, device domain.Device, check domain.Check) ProbeResult {
+	var params DNSParams
+	if err := decodeParams(check, &params); err != nil {
+		return failedResult(check, err)
+	}
+	name := params.Name
+	if name == "" {
+		name = deviceHostname(device)
+	}
+	if name == "" {
+		return failedResult(check, errors.New("no name to resolve"))
+	}
+	records := params.Records
+	if len(records) == 0 {
+		records = map[string][]string{"A": nil}
+	}
+	timeout := p.Timeout
+	if params.Timeout > 0 {
+		timeout = time.Duration(params.Timeout)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	resolver := newResolver(params.Resolver)
+
+	res := ProbeResult{
+		CheckID: check.ID,
+		Type:    check.Type,
+		Status:  domain.StatusOnline,
+	}
+	answers := make(map[string][]string, len(records))
+	var mismatches []string
+	start := time.Now()
+	for rtype, expected := range records {
+		rtype = strings.ToUpper(rtype)
+		got, err := lookup(ctx, resolver, rtype, name)
+		if err != nil {
+			res.Status = domain.StatusOffline
+			res.Error = fmt.Sprintf("%s lookup failed: %v", rtype, err)
+			break
+		}
+		answers[rtype] = got
+		if len(expected) > 0 && !sameAnswers(got, expected) {
+			mismatches = append(mismatches, fmt.Sprintf("%s answers %v, expected %v", rtype, got, expected))
+		}
+	}
+	res.Latency = time.Since(start)
+	if res.Status == domain.StatusOnline && len(mismatches) > 0 {
+		res.Status = domain.StatusDegraded
+		slices.Sort(mismatches)
+		res.Error = strings.Join(mismatches, "; ")
+	}
+	res.Details = map[string]interface{}{
+		"name":     name,
+		"resolver": params.Resolver,
+		"answers":  answers,
+	}
+	return res
+}
+
+func newResolver(server string) *net.Resolver {
+	if server == "" {
+		return net.DefaultResolver
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+func lookup(ctx context.Context, r *net.Resolver, rtype, name string) ([]string, error) {
+	var answers []string
+	switch rtype {
+	case "A", "AAAA":
+		network := "ip4"
+		if rtype == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := r.LookupNetIP(ctx, network, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			answers = append(answers, ip.Unmap().String())
+		}
+	case "CNAME":
+		cname, err := r.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, cname)
+	case "MX":
+		mxs, err := r.LookupMX(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			answers = append(answers, mx.Host)
+		}
+	case "NS":
+		nss, err := r.LookupNS(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range nss {
+			answers = append(answers, ns.Host)
+		}
+	case "TXT":
+		txts, err := r.LookupTXT(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, txts...)
+	default:
+		return nil, fmt.Errorf("unsupported record type %q", rtype)
+	}
+	return normalizeAnswers(answers), nil
+}
+
+func sameAnswers(got, expected []string) bool {
+	return slices.Equal(got, normalizeAnswers(expected))
+}
+
+// normalizeAnswers lower-cases, strips trailing dots, sorts and removes
+// duplicates so answer sets can be compared regardless of order.
+func normalizeAnswers(answers []string) []string {
+	out := make([]string, 0, len(answers))
+	for _, a := range answers {
+		out = append(out, strings.TrimSuffix(strings.ToLower(strings.TrimSpace(a)), "."))
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// deviceHostname returns the host name a device is known by: the host of
+// its URL, or its IP field when that holds a name rather than an address.
+func deviceHostname(device domain.Device) string {
+	if u, err := url.Parse(deviceURL(device, "")); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	if device.IP != "" && net.ParseIP(device.IP) == nil {
+		return device.IP
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+)
+
+type HeartbeatParams struct {
+	// Interval is how often the device is expected to push a heartbeat.
+	Interval duration `json:"interval"`
+	// Grace is how late a heartbeat may be before the check fails.
+	Grace duration `json:"grace"`
+}
+
+// HeartbeatProber checks that heartbeats pushed to a check's URL keep
+// arriving. It does not contact the device. A new check waits one
+// interval plus grace for its first heartbeat.
+type HeartbeatProber struct{}
+
+func NewHeartbeatProber() *HeartbeatProber {
+	return &HeartbeatProber{}
+}
+
+func (p *HeartbeatProber) Type() string {
+	return domain.CheckTypeHeartbeat
+}
+
+func (p *HeartbeatProber) ValidateParams(check domain.Check) error {
+	var params HeartbeatParams
+	if err := decodeParams(check, &params); err != nil {
+		return err
+	}
+	if params.Interval <= 0 {
+		return errors.New("interval is required")
+	}
+	if params.Grace < 0 {
+		return errors.New("grace must not be negative")
+	}
+	return nil
+}
+
+func (p *HeartbeatProber) Probe(ctx context.Context, device domain.Device, check domain.Check) ProbeResult {
+	var params HeartbeatParams
+	if err := decodeParams(check, &params); err != nil {
+		return failedResult(check, err)
+	}
+	if params.Interval <= 0 {
+		return failedResult(check, errors.New("no interval configured"))
+	}
+	last := check.CreatedAt
+	if check.LastHeartbeatAt != nil {
+		last = *check.LastHeartbeatAt
+	}
+	deadline := last.Add(time.Duration(params.Interval) + time.Duration(params.Grace))
+	res := ProbeResult{
+		CheckID: check.ID,
+		Type:    check.Type,
+		Status:  domain.StatusOnline,
+		Details: map[string]interface{}{"last_heartbeat_at": check.LastHeartbeatAt, "deadline": deadline},
+	}
+	if time.Now().After(deadline) {
+		res.Status = domain.StatusOffline
+		if check.LastHeartbeatAt == nil {
+			res.Error = "no heartbeat received yet"
+		} else {
+			res.Error = fmt.Sprintf("no heartbeat since %s", check.LastHeartbeatAt.Format(time.RFC3339))
+		}
+	}
+	return res
+}
@@ -0,0 +1,307 @@
+package usecase
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+)
+
+// maxBodyMatch limits how much of a response body is read for matching.
+const maxBodyMatch = 1 << 20
+
+type HTTPParams struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+	// ExpectedStatus lists accepted status codes as exact codes (200),
+	// classes ("2xx") or ranges ("200-399"). Defaults to 2xx.
+	ExpectedStatus     []statusPattern `json:"expected_status"`
+	BodyContains       string          `json:"body_contains"`
+	BodyRegex          string          `json:"body_regex"`
+	FollowRedirects    *bool           `json:"follow_redirects"`
+	MaxRedirects       int             `json:"max_redirects"`
+	Timeout            duration        `json:"timeout"`
+	InsecureSkipVerify bool            `json:"insecure_skip_verify"`
+	BasicAuth          *struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"basic_auth"`
+	BearerToken  string `json:"bearer_token"`
+	CertWarnDays int    `json:"cert_warn_days"`
+}
+
+type HTTPProber struct {
+	Timeout           time.Duration
+	CertWarnDays      int
+	transport         *http.Transport
+	insecureTransport *http.Transport
+}
+
+func NewHTTPProber() *HTTPProber {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	insecure := transport.Clone()
+	insecure.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return &HTTPProber{
+		Timeout:           10 * time.Second,
+		CertWarnDays:      certWarnDaysFromEnv(),
+		transport:         transport,
+		insecureTransport: insecure,
+	}
+}
+
+func (p *HTTPProber) Type() string {
+	return domain.CheckTypeHTTP
+}
+
+func (p *HTTPProber) ValidateParams(check domain.Check) error {
+	var params HTTPParams
+	if err := decodeParams(check, &params); err != nil {
+		return err
+	}
+	if params.BodyRegex != "" {
+		if _, err := regexp.Compile(params.BodyRegex); err != nil {
+			return fmt.Errorf("invalid body_regex: %w", err)
+		}
+	}
+	if params.MaxRedirects < 0 || params.Timeout < 0 {
+		return errors.New("max_redirects and timeout must not be negative")
+	}
+	for _, s := range params.ExpectedStatus {
+		if err := s.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *HTTPProber) Probe(ctx context.Context, device domain.Device, check domain.Check) ProbeResult {
+	var params HTTPParams
+	if err := decodeParams(check, &params); err != nil {
+		return failedResult(check, err)
+	}
+	url := deviceURL(device, params.URL)
+	if url == "" {
+		return failedResult(check, errors.New("no URL to request"))
+	}
+	var bodyRegex *regexp.Regexp
+	if params.BodyRegex != "" {
+		re, err := regexp.Compile(params.BodyRegex)
+		if err != nil {
+			return failedResult(check, fmt.Errorf("invalid body_regex: %w", err))
+		}
+		bodyRegex = re
+	}
+
+	method := params.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	if params.Body != "" {
+		body = strings.NewReader(params.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), url, body)
+	if err != nil {
+		return failedResult(check, err)
+	}
+	for k, v := range params.Headers {
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	if params.BasicAuth != nil {
+		req.SetBasicAuth(params.BasicAuth.Username, params.BasicAuth.Password)
+	}
+	if params.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+params.BearerToken)
+	}
+
+	start := time.Now()
+	resp, err := p.client(params).Do(req)
+	if err != nil {
+		return failedResult(check, err)
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyMatch))
+	latency := time.Since(start)
+
+	res := ProbeResult{
+		CheckID: check.ID,
+		Type:    check.Type,
+		Status:  domain.StatusOnline,
+		Latency: latency,
+		Details: map[string]interface{}{
+			"url":         url,
+			"status_code": resp.StatusCode,
+			"final_url":   resp.Request.URL.String(),
+		},
+	}
+	switch {
+	case err != nil:
+		res.Status = domain.StatusOffline
+		res.Error = "reading body: " + err.Error()
+	case !statusExpected(resp.StatusCode, params.ExpectedStatus):
+		res.Status = domain.StatusOffline
+		res.Error = fmt.Sprintf("unexpected status %s", resp.Status)
+	case params.BodyContains != "" && !strings.Contains(string(content), params.BodyContains):
+		res.Status = domain.StatusOffline
+		res.Error = fmt.Sprintf("body does not contain %q", params.BodyContains)
+	case bodyRegex != nil && !bodyRegex.Match(content):
+		res.Status = domain.StatusOffline
+		res.Error = fmt.Sprintf("body does not match %q", params.BodyRegex)
+	}
+
+	if resp.TLS != nil {
+		res.Certificate = p.certificate(device, check, resp)
+		if res.Certificate != nil && res.Status == domain.StatusOnline {
+			warnDays := p.CertWarnDays
+			if params.CertWarnDays > 0 {
+				warnDays = params.CertWarnDays
+			}
+			res.Status, res.Error = certificateStatus(res.Certificate, warnDays)
+		}
+	}
+	return res
+}
+
+func (p *HTTPProber) certificate(device domain.Device, check domain.Check, resp *http.Response) *domain.Certificate {
+	u := resp.Request.URL
+	cert := certificateFromState(*resp.TLS, u.Hostname())
+	if cert == nil {
+		return nil
+	}
+	cert.DeviceID = device.ID
+	cert.CheckID = check.ID
+	cert.Host = u.Hostname()
+	cert.Port = 443
+	if port, err := strconv.Atoi(u.Port()); err == nil {
+		cert.Port = port
+	}
+	return cert
+}
+
+func (p *HTTPProber) client(params HTTPParams) *http.Client {
+	client := &http.Client{Transport: p.transport, Timeout: p.Timeout}
+	if params.InsecureSkipVerify {
+		client.Transport = p.insecureTransport
+	}
+	if params.Timeout > 0 {
+		client.Timeout = time.Duration(params.Timeout)
+	}
+	maxRedirects := params.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = 10
+	}
+	follow := params.FollowRedirects == nil || *params.FollowRedirects
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !follow {
+			return http.ErrUseLastResponse
+		}
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return nil
+	}
+	return client
+}
+
+// deviceURL picks the URL for an HTTP check: the check's own URL, then the
+// device URL, then the device IP for devices that still store a URL there.
+func deviceURL(device domain.Device, override string) string {
+	switch {
+	case override != "":
+		return override
+	case device.URL != "":
+		return device.URL
+	case strings.HasPrefix(device.IP, "http"):
+		return device.IP
+	}
+	return ""
+}
+
+func statusExpected(code int, patterns []statusPattern) bool {
+	if len(patterns) == 0 {
+		return code >= 200 && code < 300
+	}
+	for _, p := range patterns {
+		if p.match(code) {
+			return true
+		}
+	}
+	return false
+}
+
+// statusPattern is an expected HTTP status: 200, "2xx" or "200-399".
+type statusPattern string
+
+func (s *statusPattern) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case float64:
+		*s = statusPattern(strconv.Itoa(int(v)))
+	case string:
+		*s = statusPattern(strings.ToLower(strings.TrimSpace(v)))
+	default:
+		return fmt.Errorf("invalid status pattern %s", b)
+	}
+	return nil
+}
+
+func (s statusPattern) match(code int) bool {
+	str := string(s)
+	if len(str) == 3 && strings.HasSuffix(str, "xx") {
+		class, err := strconv.Atoi(str[:1])
+		return err == nil && code/100 == class
+	}
+	if lo, hi, ok := strings.Cut(str, "-"); ok {
+		l, err1 := strconv.Atoi(lo)
+		h, err2 := strconv.Atoi(hi)
+		return err1 == nil && err2 == nil && code >= l && code <= h
+	}
+	exact, err := strconv.Atoi(str)
+	return err == nil && code == exact
+}
+
+// validate reports patterns that match no status code, such as "2xx,abc"
+// or "600-".
+func (s statusPattern) validate() error {
+	str := string(s)
+	var ok bool
+	if len(str) == 3 && strings.HasSuffix(str, "xx") {
+		ok = str[0] >= '1' && str[0] <= '5'
+	} else if lo, hi, isRange := strings.Cut(str, "-"); isRange {
+		l, h := statusCode(lo), statusCode(hi)
+		ok = l != 0 && h != 0 && l <= h
+	} else {
+		ok = statusCode(str) != 0
+	}
+	if !ok {
+		return fmt.Errorf("invalid expected_status %q", str)
+	}
+	return nil
+}
+
+// statusCode parses an HTTP status code, returning 0 when s is not one.
+func statusCode(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 100 || n > 599 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/ping"
+)
+
+type ICMPParams struct {
+	Host     string   `json:"host"`
+	Count    int      `json:"count"`
+	Interval duration `json:"interval"`
+	Timeout  duration `json:"timeout"`
+	Size     *int     `json:"size"`
+}
+
+type ICMPProber struct {
+	Pinger *ping.Pinger
+}
+
+func NewICMPProber(pinger *ping.Pinger) *ICMPProber {
+	return &ICMPProber{Pinger: pinger}
+}
+
+func (p *ICMPProber) Type() string {
+	return domain.CheckTypeICMP
+}
+
+func (p *ICMPProber) ValidateParams(check domain.Check) error {
+	var params ICMPParams
+	if err := decodeParams(check, &params); err != nil {
+		return err
+	}
+	if params.Count < 0 || params.Interval < 0 || params.Timeout < 0 || (params.Size != nil && *params.Size < 0) {
+		return errors.New("count, interval, timeout and size must not be negative")
+	}
+	if params.Size != nil && *params.Size > ping.MaxSize {
+		return fmt.Errorf("size must be at most %d", ping.MaxSize)
+	}
+	return nil
+}
+
+func (p *ICMPProber) Probe(ctx context.Context, device domain.Device, check domain.Check) ProbeResult {
+	var params ICMPParams
+	if err := decodeParams(check, &params); err != nil {
+		return failedResult(check, err)
+	}
+	host := params.Host
+	if host == "" {
+		host = device.IP
+	}
+	if host == "" {
+		return failedResult(check, errors.New("no host to ping"))
+	}
+
+	pinger := *p.Pinger
+	if params.Count > 0 {
+		pinger.Count = params.Count
+	}
+	if params.Interval > 0 {
+		pinger.Interval = time.Duration(params.Interval)
+	}
+	if params.Timeout > 0 {
+		pinger.Timeout = time.Duration(params.Timeout)
+	}
+	if params.Size != nil {
+		pinger.Size = *params.Size
+	}
+
+	result, err := pinger.Ping(ctx, host)
+	if err != nil {
+		return failedResult(check, err)
+	}
+
+	res := ProbeResult{
+		CheckID: check.ID,
+		Type:    check.Type,
+		Status:  domain.StatusOnline,
+		Latency: result.AvgRTT,
+		Loss:    &result.Loss,
+		Details: map[string]interface{}{
+			"addr":       result.Addr,
+			"sent":       result.Sent,
+			"received":   result.Received,
+			"loss":       result.Loss,
+			"rtt_min_ms": ms(result.MinRTT),
+			"rtt_avg_ms": ms(result.AvgRTT),
+			"rtt_max_ms": ms(result.MaxRTT),
+		},
+	}
+	if !result.Alive() {
+		res.Status = domain.StatusOffline
+		res.Error = fmt.Sprintf("%.0f%% packet loss", result.Loss)
+	}
+	return res
+}
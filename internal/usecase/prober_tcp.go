@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+)
+
+type TCPParams struct {
+	Host    string   `json:"host"`
+	Ports   []int    `json:"ports"`
+	Timeout duration `json:"timeout"`
+	// Banner, when set, must prefix the first line the server sends after
+	// the connection is established, e.g. "SSH-2.0".
+	Banner string `json:"banner"`
+}
+
+type TCPPortResult struct {
+	Port      int     `json:"port"`
+	Open      bool    `json:"open"`
+	LatencyMs float64 `json:"latency_ms"`
+	Banner    string  `json:"banner,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type TCPProber struct {
+	Dialer  net.Dialer
+	Timeout time.Duration
+}
+
+func NewTCPProber() *TCPProber {
+	return &TCPProber{Timeout: 3 * time.Second}
+}
+
+func (p *TCPProber) Type() string {
+	return domain.CheckTypeTCP
+}
+
+func (p *TCPProber) ValidateParams(check domain.Check) error {
+	var params TCPParams
+	if err := decodeParams(check, &params); err != nil {
+		return err
+	}
+	if len(params.Ports) == 0 {
+		return errors.New("no ports configured")
+	}
+	for _, port := range params.Ports {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("invalid port %d", port)
+		}
+	}
+	return nil
+}
+
+func (p *TCPProber) Probe(ctx context.Context, device domain.Device, check domain.Check) ProbeResult {
+	var params TCPParams
+	if err := decodeParams(check, &params); err != nil {
+		return failedResult(check, err)
+	}
+	host := params.Host
+	if host == "" {
+		host = device.IP
+	}
+	if host == "" {
+		return failedResult(check, errors.New("no host to connect to"))
+	}
+	if len(params.Ports) == 0 {
+		return failedResult(check, errors.New("no ports configured"))
+	}
+	timeout := p.Timeout
+	if params.Timeout > 0 {
+		timeout = time.Duration(params.Timeout)
+	}
+
+	ports := make([]TCPPortResult, len(params.Ports))
+	var wg sync.WaitGroup
+	for i, port := range params.Ports {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ports[i] = p.probePort(ctx, host, port, timeout, params.Banner)
+		}()
+	}
+	wg.Wait()
+
+	open := 0
+	var total float64
+	var failures []string
+	for _, port := range ports {
+		if port.Open {
+			open++
+			total += port.LatencyMs
+		} else {
+			failures = append(failures, fmt.Sprintf("%d: %s", port.Port, port.Error))
+		}
+	}
+
+	res := ProbeResult{
+		CheckID: check.ID,
+		Type:    check.Type,
+		Status:  domain.StatusOnline,
+		Details: map[string]interface{}{"host": host, "ports": ports},
+	}
+	if open > 0 {
+		res.Latency = time.Duration(total / float64(open) * float64(time.Millisecond))
+	}
+	switch {
+	case open == 0:
+		res.Status = domain.StatusOffline
+	case open < len(ports):
+		res.Status = domain.StatusDegraded
+	}
+	if len(failures) > 0 {
+		res.Error = strings.Join(failures, "; ")
+	}
+	return res
+}
+
+func (p *TCPProber) probePort(ctx context.Context, host string, port int, timeout time.Duration, banner string) TCPPortResult {
+	result := TCPPortResult{Port: port}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	conn, err := p.Dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer conn.Close()
+	result.LatencyMs = ms(time.Since(start))
+
+	if banner == "" {
+		result.Open = true
+		return result
+	}
+
+	deadline, _ := ctx.Deadline()
+	conn.SetReadDeadline(deadline)
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	result.Banner = strings.TrimRight(line, "\r\n")
+	if err != nil && result.Banner == "" {
+		result.Error = "no banner: " + err.Error()
+		return result
+	}
+	if !strings.HasPrefix(result.Banner, banner) {
+		result.Error = fmt.Sprintf("banner %q does not start with %q", result.Banner, banner)
+		return result
+	}
+	result.Open = true
+	return result
+}
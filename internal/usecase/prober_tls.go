@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+)
+
+type TLSParams struct {
+	Host       string   `json:"host"`
+	Port       int      `json:"port"`
+	ServerName string   `json:"server_name"`
+	WarnDays   int      `json:"warn_days"`
+	Timeout    duration `json:"timeout"`
+}
+
+type TLSProber struct {
+	Timeout  time.Duration
+	WarnDays int
+}
+
+func NewTLSProber() *TLSProber {
+	return &TLSProber{Timeout: 5 * time.Second, WarnDays: certWarnDaysFromEnv()}
+}
+
+func (p *TLSProber) Type() string {
+	return domain.CheckTypeTLS
+}
+
+func (p *TLSProber) ValidateParams(check domain.Check) error {
+	var params TLSParams
+	if err := decodeParams(check, &params); err != nil {
+		return err
+	}
+	if params.Port < 0 || params.Port > 65535 {
+		return fmt.Errorf("invalid port %d", params.Port)
+	}
+	return nil
+}
+
+func (p *TLSProber) Probe(ctx context.Context, device domain.Device, check domain.Check) ProbeResult {
+	var params TLSParams
+	if err := decodeParams(check, &params); err != nil {
+		return failedResult(check, err)
+	}
+	host := params.Host
+	if host == "" {
+		host = device.IP
+	}
+	if host == "" {
+		return failedResult(check, errors.New("no host to connect to"))
+	}
+	port := params.Port
+	if port == 0 {
+		port = 443
+	}
+	serverName := params.ServerName
+	if serverName == "" {
+		serverName = host
+	}
+	timeout := p.Timeout
+	if params.Timeout > 0 {
+		timeout = time.Duration(params.Timeout)
+	}
+	warnDays := p.WarnDays
+	if params.WarnDays > 0 {
+		warnDays = params.WarnDays
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Verification is done separately so certificates are recorded even
+	// when they are expired or untrusted.
+	dialer := &tls.Dialer{Config: &tls.Config{ServerName: serverName, InsecureSkipVerify: true}}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return failedResult(check, err)
+	}
+	defer conn.Close()
+	latency := time.Since(start)
+
+	cert := certificateFromState(conn.(*tls.Conn).ConnectionState(), serverName)
+	if cert == nil {
+		return failedResult(check, errors.New("no certificate presented"))
+	}
+	cert.DeviceID = device.ID
+	cert.CheckID = check.ID
+	cert.Host = host
+	cert.Port = port
+
+	res := ProbeResult{
+		CheckID:     check.ID,
+		Type:        check.Type,
+		Latency:     latency,
+		Certificate: cert,
+	}
+	res.Status, res.Error = certificateStatus(cert, warnDays)
+	res.Details = certificateDetails(cert)
+	return res
+}
+
+// certificateFromState describes the chain presented in a TLS handshake
+// and verifies it against the system roots for serverName.
+func certificateFromState(state tls.ConnectionState, serverName string) *domain.Certificate {
+	if len(state.PeerCertificates) == 0 {
+		return nil
+	}
+	leaf := state.PeerCertificates[0]
+	cert := &domain.Certificate{
+		Subject:      leaf.Subject.String(),
+		Issuer:       leaf.Issuer.String(),
+		SANs:         domain.StringList(leaf.DNSNames),
+		SerialNumber: leaf.SerialNumber.String(),
+		NotBefore:    leaf.NotBefore,
+		NotAfter:     leaf.NotAfter,
+		CheckedAt:    time.Now(),
+	}
+	for _, ip := range leaf.IPAddresses {
+		cert.SANs = append(cert.SANs, ip.String())
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range state.PeerCertificates[1:] {
+		intermediates.AddCert(c)
+		if c.NotAfter.Before(cert.NotAfter) {
+			cert.NotAfter = c.NotAfter
+		}
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{DNSName: serverName, Intermediates: intermediates}); err != nil {
+		cert.ValidationError = err.Error()
+	}
+	return cert
+}
+
+// certificateStatus maps a certificate to a check status: offline once it
+// has expired, degraded when it does not validate, warning within warnDays
+// of expiry.
+func certificateStatus(cert *domain.Certificate, warnDays int) (string, string) {
+	left := time.Until(cert.NotAfter)
+	switch {
+	case left <= 0:
+		return domain.StatusOffline, fmt.Sprintf("certificate expired on %s", cert.NotAfter.Format(time.DateOnly))
+	case cert.ValidationError != "":
+		return domain.StatusDegraded, cert.ValidationError
+	case left < time.Duration(warnDays)*24*time.Hour:
+		return domain.StatusWarning, fmt.Sprintf("certificate expires in %d days", int(left.Hours()/24))
+	}
+	return domain.StatusOnline, ""
+}
+
+func certificateDetails(cert *domain.Certificate) map[string]interface{} {
+	return map[string]interface{}{
+		"host":             cert.Host,
+		"port":             cert.Port,
+		"subject":          cert.Subject,
+		"issuer":           cert.Issuer,
+		"sans":             cert.SANs,
+		"not_after":        cert.NotAfter,
+		"days_left":        int(time.Until(cert.NotAfter).Hours() / 24),
+		"validation_error": cert.ValidationError,
+	}
+}
+
+// certWarnDaysFromEnv reads CERT_WARN_DAYS, defaulting to 14.
+func certWarnDaysFromEnv() int {
+	if v, err := strconv.Atoi(os.Getenv("CERT_WARN_DAYS")); err == nil && v > 0 {
+		return v
+	}
+	return 14
+}
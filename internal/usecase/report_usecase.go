@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"sort"
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/repository"
+)
+
+const (
+	GroupByDevice   = "device"
+	GroupByType     = "type"
+	GroupByLocation = "location"
+)
+
+type ReportUsecase struct {
+	DeviceRepo   *repository.DeviceRepository
+	LogRepo      *repository.LogRepository
+	TypeRepo     *repository.DeviceTypeRepository
+	TypeMapRepo  *repository.DeviceTypeMapRepository
+	LocationRepo *repository.LocationRepository
+	Maintenance  *MaintenanceUsecase
+}
+
+func NewReportUsecase(deviceRepo *repository.DeviceRepository, logRepo *repository.LogRepository, typeRepo *repository.DeviceTypeRepository, typeMapRepo *repository.DeviceTypeMapRepository, locationRepo *repository.LocationRepository, maintenance *MaintenanceUsecase) *ReportUsecase {
+	return &ReportUsecase{
+		DeviceRepo:   deviceRepo,
+		LogRepo:      logRepo,
+		TypeRepo:     typeRepo,
+		TypeMapRepo:  typeMapRepo,
+		LocationRepo: locationRepo,
+		Maintenance:  maintenance,
+	}
+}
+
+// GetAvailability computes availability over [from, to) from the status
+// transitions in the log, grouped per device, device type or location.
+// A device counts once for every type it has; devices without a type or
+// location are left out of those groupings. Time a device spends in
+// maintenance windows is not counted at all.
+func (u *ReportUsecase) GetAvailability(from, to time.Time, groupBy string) ([]domain.Availability, error) {
+	if groupBy != GroupByDevice && groupBy != GroupByType && groupBy != GroupByLocation {
+		return nil, &ValidationError{Msg: "group_by must be device, type or location"}
+	}
+	devices, err := u.DeviceRepo.GetAllDevices()
+	if err != nil {
+		return nil, err
+	}
+	initial, err := u.LogRepo.GetStatusesAt(from)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := u.LogRepo.GetTransitions(from, to)
+	if err != nil {
+		return nil, err
+	}
+	byDevice := make(map[uint][]domain.Log)
+	for _, l := range logs {
+		byDevice[l.DeviceID] = append(byDevice[l.DeviceID], l)
+	}
+
+	maps, err := u.TypeMapRepo.GetAllMaps()
+	if err != nil {
+		return nil, err
+	}
+	deviceTypes := make(map[uint][]uint)
+	for _, m := range maps {
+		deviceTypes[m.DeviceID] = append(deviceTypes[m.DeviceID], m.TypeID)
+	}
+
+	end := to
+	if now := time.Now(); now.Before(end) {
+		end = now
+	}
+	perDevice := make(map[uint]domain.Availability, len(devices))
+	for _, device := range devices {
+		status, ok := initial[device.ID]
+		if !ok {
+			status = device.Status
+			if l := byDevice[device.ID]; len(l) > 0 {
+				status = l[0].OldStatus
+			}
+		}
+		start := from
+		if device.CreatedAt.After(start) {
+			start = device.CreatedAt
+		}
+		if !start.Before(end) {
+			continue
+		}
+		var maintenance []domain.Period
+		if u.Maintenance != nil {
+			if maintenance, err = u.Maintenance.DevicePeriods(device, deviceTypes[device.ID], start, end); err != nil {
+				return nil, err
+			}
+		}
+		a := deviceAvailability(start, end, status, byDevice[device.ID], maintenance)
+		a.ID = device.ID
+		a.Name = device.Name
+		perDevice[device.ID] = a
+	}
+
+	switch groupBy {
+	case GroupByType:
+		types, err := u.TypeRepo.GetAllDeviceTypes()
+		if err != nil {
+			return nil, err
+		}
+		groups := make(map[uint]domain.Availability, len(types))
+		for _, t := range types {
+			groups[t.ID] = domain.Availability{ID: t.ID, Name: t.TypeName}
+		}
+		for _, m := range maps {
+			g, ok := groups[m.TypeID]
+			a, exists := perDevice[m.DeviceID]
+			if ok && exists {
+				groups[m.TypeID] = addAvailability(g, a)
+			}
+		}
+		return sortedAvailability(groups), nil
+	case GroupByLocation:
+		locations, err := u.LocationRepo.GetAllLocations()
+		if err != nil {
+			return nil, err
+		}
+		groups := make(map[uint]domain.Availability, len(locations))
+		for _, l := range locations {
+			groups[l.ID] = domain.Availability{ID: l.ID, Name: l.Name}
+		}
+		for _, device := range devices {
+			g, ok := groups[device.LocationID]
+			a, exists := perDevice[device.ID]
+			if ok && exists {
+				groups[device.LocationID] = addAvailability(g, a)
+			}
+		}
+		return sortedAvailability(groups), nil
+	}
+	return sortedAvailability(perDevice), nil
+}
+
+// deviceAvailability walks the transitions of one device in [start, end),
+// starting from status. Time spent in an empty (unknown) status or in one
+// of the excluded periods, which must be sorted, is not observed. Downtime
+// observed after being up starts an outage, including being down at start;
+// an outage interrupted only by excluded time stays one outage.
+func deviceAvailability(start, end time.Time, status string, logs []domain.Log, excluded []domain.Period) domain.Availability {
+	a := domain.Availability{Devices: 1}
+	cur := start
+	inOutage := false
+	observe := func(d float64) {
+		a.ObservedSeconds += d
+		if !isDown(status) {
+			a.UptimeSeconds += d
+			return
+		}
+		a.DowntimeSeconds += d
+		if !inOutage {
+			a.Outages++
+			inOutage = true
+		}
+	}
+	account := func(until time.Time) {
+		if !until.After(cur) {
+			return
+		}
+		if status != "" {
+			from := cur
+			for _, p := range excluded {
+				if !p.End.After(from) {
+					continue
+				}
+				if !p.Start.Before(until) {
+					break
+				}
+				if p.Start.After(from) {
+					observe(p.Start.Sub(from).Seconds())
+				}
+				from = p.End
+			}
+			if until.After(from) {
+				observe(until.Sub(from).Seconds())
+			}
+		}
+		cur = until
+	}
+
+	for _, l := range logs {
+		if !l.Logtime.Before(end) {
+			break
+		}
+		account(l.Logtime)
+		status = l.NewStatus
+		if !isDown(status) {
+			inOutage = false
+		}
+	}
+	account(end)
+	return finishAvailability(a)
+}
+
+func addAvailability(g, a domain.Availability) domain.Availability {
+	g.Devices += a.Devices
+	g.ObservedSeconds += a.ObservedSeconds
+	g.UptimeSeconds += a.UptimeSeconds
+	g.DowntimeSeconds += a.DowntimeSeconds
+	g.Outages += a.Outages
+	return finishAvailability(g)
+}
+
+// finishAvailability derives the ratios. MTTR is downtime per outage and
+// MTBF uptime per outage; both are zero without outages.
+func finishAvailability(a domain.Availability) domain.Availability {
+	a.UptimePct = 0
+	if a.ObservedSeconds > 0 {
+		a.UptimePct = a.UptimeSeconds / a.ObservedSeconds * 100
+	}
+	a.MTTRSeconds, a.MTBFSeconds = 0, 0
+	if a.Outages > 0 {
+		a.MTTRSeconds = a.DowntimeSeconds / float64(a.Outages)
+		a.MTBFSeconds = a.UptimeSeconds / float64(a.Outages)
+	}
+	return a
+}
+
+func sortedAvailability(m map[uint]domain.Availability) []domain.Availability {
+	out := make([]domain.Availability, 0, len(m))
+	for _, a := range m {
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
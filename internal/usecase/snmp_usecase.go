@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+	"github.com/simonaditiabbp/netmon-backend/internal/repository"
+	"github.com/simonaditiabbp/netmon-backend/internal/snmp"
+)
+
+type SNMPUsecase struct {
+	Repo        *repository.SNMPRepository
+	TypeMapRepo *repository.DeviceTypeMapRepository
+	Poller      *snmp.Poller
+	Retention   time.Duration
+}
+
+// NewSNMPUsecase keeps polled values for METRICS_RETENTION (default 30
+// days), like device metrics.
+func NewSNMPUsecase(repo *repository.SNMPRepository, typeMapRepo *repository.DeviceTypeMapRepository) *SNMPUsecase {
+	retention := 30 * 24 * time.Hour
+	if v, err := time.ParseDuration(os.Getenv("METRICS_RETENTION")); err == nil && v > 0 {
+		retention = v
+	}
+	return &SNMPUsecase{Repo: repo, TypeMapRepo: typeMapRepo, Poller: snmp.NewPoller(), Retention: retention}
+}
+
+// PruneMetrics deletes polled values older than the retention period.
+func (u *SNMPUsecase) PruneMetrics() (int64, error) {
+	return u.Repo.DeleteMetricsBefore(time.Now().Add(-u.Retention))
+}
+
+// SeedBuiltinOIDSets makes the OID sets shipped with the poller available
+// for attaching to device types. Existing sets are left untouched.
+func (u *SNMPUsecase) SeedBuiltinOIDSets() error {
+	for _, set := range snmp.BuiltinOIDSets {
+		if err := u.Repo.EnsureOIDSet(&set); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *SNMPUsecase) GetPolledDevices() ([]domain.Device, error) {
+	return u.Repo.GetPolledDevices()
+}
+
+// PollDevice reads the base OIDs plus the OID sets of the device's types
+// and stores every value as a metric point.
+func (u *SNMPUsecase) PollDevice(ctx context.Context, device domain.Device) {
+	if ctx.Err() != nil {
+		return
+	}
+	cred, err := u.Repo.GetCredential(device.ID)
+	if err != nil {
+		log.Printf("Error fetching SNMP credential for device %s: %v", device.Name, err)
+		return
+	}
+	host := snmpHost(device)
+	if host == "" {
+		log.Printf("SNMP poll skipped for device %s: no host", device.Name)
+		return
+	}
+
+	oids, err := u.deviceOIDs(device.ID)
+	if err != nil {
+		log.Printf("Error fetching OID sets for device %s: %v", device.Name, err)
+		return
+	}
+	values, err := u.Poller.Poll(host, *cred, oids)
+	if err != nil {
+		log.Printf("SNMP poll failed for device %s: %v", device.Name, err)
+		return
+	}
+
+	now := time.Now()
+	metrics := make([]domain.SNMPMetric, 0, len(values))
+	for _, v := range values {
+		metrics = append(metrics, domain.SNMPMetric{
+			DeviceID: device.ID,
+			Name:     v.Name,
+			OID:      v.OID,
+			Instance: v.Instance,
+			Value:    v.Value,
+			Text:     v.Text,
+			PolledAt: now,
+		})
+	}
+	if err := u.Repo.InsertMetrics(metrics); err != nil {
+		log.Printf("Error storing SNMP metrics for device %s: %v", device.Name, err)
+	}
+}
+
+func (u *SNMPUsecase) deviceOIDs(deviceID uint) ([]domain.SNMPOID, error) {
+	types, err := u.TypeMapRepo.GetDeviceTypes(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	typeIDs := make([]uint, 0, len(types))
+	for _, t := range types {
+		typeIDs = append(typeIDs, t.ID)
+	}
+	sets, err := u.Repo.GetOIDSetsByTypes(typeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	oids := append([]domain.SNMPOID{}, snmp.BaseOIDs...)
+	seen := make(map[string]bool)
+	for _, o := range oids {
+		seen[o.OID] = true
+	}
+	for _, set := range sets {
+		for _, o := range set.OIDs {
+			if !seen[o.OID] {
+				seen[o.OID] = true
+				oids = append(oids, o)
+			}
+		}
+	}
+	return oids, nil
+}
+
+func (u *SNMPUsecase) GetCredential(deviceID uint) (*domain.SNMPCredential, error) {
+	return u.Repo.GetCredential(deviceID)
+}
+
+func (u *SNMPUsecase) SaveCredential(cred *domain.SNMPCredential) error {
+	if cred.Version == "" {
+		cred.Version = domain.SNMPVersion2c
+	}
+	if err := snmp.ValidateCredential(*cred); err != nil {
+		return &ValidationError{Msg: err.Error()}
+	}
+	return u.Repo.SaveCredential(cred)
+}
+
+func (u *SNMPUsecase) DeleteCredential(deviceID uint) error {
+	return u.Repo.DeleteCredential(deviceID)
+}
+
+func (u *SNMPUsecase) CreateOIDSet(set *domain.OIDSet) error {
+	if err := validateOIDSet(set); err != nil {
+		return err
+	}
+	return u.Repo.CreateOIDSet(set)
+}
+
+func (u *SNMPUsecase) UpdateOIDSet(set *domain.OIDSet) error {
+	if err := validateOIDSet(set); err != nil {
+		return err
+	}
+	return u.Repo.UpdateOIDSet(set)
+}
+
+func (u *SNMPUsecase) GetAllOIDSets() ([]domain.OIDSet, error) {
+	return u.Repo.GetAllOIDSets()
+}
+
+func (u *SNMPUsecase) GetOIDSetByID(id uint) (*domain.OIDSet, error) {
+	return u.Repo.GetOIDSetByID(id)
+}
+
+func (u *SNMPUsecase) DeleteOIDSet(id uint) error {
+	return u.Repo.DeleteOIDSet(id)
+}
+
+func (u *SNMPUsecase) GetOIDSetsByType(typeID uint) ([]domain.OIDSet, error) {
+	return u.Repo.GetOIDSetsByTypes([]uint{typeID})
+}
+
+func (u *SNMPUsecase) SetTypeOIDSets(typeID uint, setIDs []uint) error {
+	return u.Repo.SetTypeOIDSets(typeID, setIDs)
+}
+
+func (u *SNMPUsecase) GetMetrics(deviceID uint, name string, from, to time.Time) ([]domain.SNMPMetric, error) {
+	return u.Repo.GetMetrics(deviceID, name, from, to)
+}
+
+func validateOIDSet(set *domain.OIDSet) error {
+	if set.Name == "" {
+		return &ValidationError{Msg: "name is required"}
+	}
+	for _, o := range set.OIDs {
+		if o.Name == "" || o.OID == "" {
+			return &ValidationError{Msg: "every OID needs a name and an oid"}
+		}
+	}
+	return nil
+}
+
+// snmpHost returns the address SNMP agents are reached at: the device IP,
+// or the host of its URL for devices that only have one.
+func snmpHost(device domain.Device) string {
+	if device.IP != "" && !strings.HasPrefix(device.IP, "http") {
+		return device.IP
+	}
+	return deviceHostname(device)
+}
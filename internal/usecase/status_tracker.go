@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/simonaditiabbp/netmon-backend/internal/domain"
+)
+
+// StatusTracker turns the status derived from each check run into the
+// status stored on the device. A device is only marked offline after
+// FailThreshold consecutive offline results and only leaves offline after
+// RecoverThreshold consecutive results that are not offline. Changes
+// between the other statuses apply at once. A device whose status changed
+// FlapThreshold times within FlapWindow is reported as flapping until the
+// number of changes in the window drops below half the threshold.
+type StatusTracker struct {
+	FailThreshold    int
+	RecoverThreshold int
+	FlapThreshold    int
+	FlapWindow       time.Duration
+
+	mu     sync.Mutex
+	states map[uint]*trackedStatus
+}
+
+type trackedStatus struct {
+	stable   string // status after hysteresis, ignoring flapping
+	pending  string // status waiting to reach its threshold
+	count    int    // consecutive results of pending
+	changes  []time.Time
+	flapping bool
+}
+
+// NewStatusTrackerFromEnv reads STATUS_FAIL_THRESHOLD (default 3),
+// STATUS_RECOVER_THRESHOLD (default 2), FLAP_THRESHOLD (default 6, 0
+// disables flap detection) and FLAP_WINDOW (default 10m).
+func NewStatusTrackerFromEnv() *StatusTracker {
+	t := &StatusTracker{
+		FailThreshold:    3,
+		RecoverThreshold: 2,
+		FlapThreshold:    6,
+		FlapWindow:       10 * time.Minute,
+		states:           make(map[uint]*trackedStatus),
+	}
+	if v, err := strconv.Atoi(os.Getenv("STATUS_FAIL_THRESHOLD")); err == nil && v > 0 {
+		t.FailThreshold = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("STATUS_RECOVER_THRESHOLD")); err == nil && v > 0 {
+		t.RecoverThreshold = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("FLAP_THRESHOLD")); err == nil && v >= 0 {
+		t.FlapThreshold = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("FLAP_WINDOW")); err == nil && v > 0 {
+		t.FlapWindow = v
+	}
+	return t
+}
+
+// Observe records the status derived from one check run of device and
+// returns the status the device should have now. Devices seen for the
+// first time start from their stored status; without one the observed
+// status applies at once.
+func (t *StatusTracker) Observe(device domain.Device, observed string, at time.Time) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	st, ok := t.states[device.ID]
+	if !ok {
+		st = &trackedStatus{stable: device.Status, flapping: device.Status == domain.StatusFlapping}
+		if st.flapping {
+			st.stable = ""
+		}
+		t.states[device.ID] = st
+	}
+
+	switch {
+	case st.stable == "":
+		st.stable = observed
+		st.pending, st.count = "", 0
+	case observed == st.stable:
+		st.pending, st.count = "", 0
+	default:
+		// Results only need to agree on being down or not to count
+		// towards the same threshold.
+		if st.pending == "" || isDown(observed) != isDown(st.pending) {
+			st.count = 0
+		}
+		st.pending = observed
+		st.count++
+		if st.count >= t.threshold(st.stable, observed) {
+			st.stable = observed
+			st.pending, st.count = "", 0
+			st.changes = append(st.changes, at)
+		}
+	}
+
+	if t.FlapThreshold > 0 {
+		cutoff := at.Add(-t.FlapWindow)
+		i := 0
+		for i < len(st.changes) && st.changes[i].Before(cutoff) {
+			i++
+		}
+		st.changes = st.changes[i:]
+		switch {
+		case len(st.changes) >= t.FlapThreshold:
+			st.flapping = true
+		case len(st.changes) < (t.FlapThreshold+1)/2:
+			st.flapping = false
+		}
+	}
+	if st.flapping {
+		return domain.StatusFlapping
+	}
+	return st.stable
+}
+
+// Override makes observed the status of device at once, bypassing the
+// thresholds and ending flapping, and returns it. It is used for checks a
+// user asked for, whose outcome should show right away.
+func (t *StatusTracker) Override(device domain.Device, observed string, at time.Time) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	st, ok := t.states[device.ID]
+	if !ok {
+		st = &trackedStatus{}
+		t.states[device.ID] = st
+	}
+	if st.stable != "" && st.stable != observed {
+		st.changes = append(st.changes, at)
+	}
+	st.stable, st.pending, st.count, st.flapping = observed, "", 0, false
+	return observed
+}
+
+// Forget drops the state kept for a device.
+func (t *StatusTracker) Forget(deviceID uint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.states, deviceID)
+}
+
+// Reset drops the state kept for all devices, so they start again from
+// their stored status.
+func (t *StatusTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.states = make(map[uint]*trackedStatus)
+}
+
+// threshold returns how many consecutive results are needed to move from
+// one status to another.
+func (t *StatusTracker) threshold(from, to string) int {
+	switch {
+	case isDown(to):
+		return t.FailThreshold
+	case isDown(from):
+		return t.RecoverThreshold
+	}
+	return 1
+}
@@ -1,36 +1,115 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/simonaditiabbp/netmon-backend/internal/agent"
 	"github.com/simonaditiabbp/netmon-backend/internal/db"
 	"github.com/simonaditiabbp/netmon-backend/internal/delivery"
+	"github.com/simonaditiabbp/netmon-backend/internal/events"
+	"github.com/simonaditiabbp/netmon-backend/internal/leader"
 	"github.com/simonaditiabbp/netmon-backend/internal/repository"
+	"github.com/simonaditiabbp/netmon-backend/internal/scheduler"
 	"github.com/simonaditiabbp/netmon-backend/internal/usecase"
 )
 
 func main() {
+	// In agent mode the binary only probes the devices a remote server
+	// assigns to it.
+	if len(os.Args) > 1 && os.Args[1] == "agent" {
+		if err := agent.New(agent.ConfigFromEnv()).Run(context.Background()); err != nil {
+			log.Fatalf("Agent stopped: %v", err)
+		}
+		return
+	}
+
 	// Initialize database connection
 	database := db.InitDB()
+	db.Migrate(database)
+	sqlDB, err := database.DB()
+	if err != nil {
+		log.Fatalf("Failed to get database handle: %v", err)
+	}
 
 	deviceRepo := repository.NewDeviceRepository(database)
 	deviceTypeRepo := repository.NewDeviceTypeRepository(database)
 	deviceTypeMapRepo := repository.NewDeviceTypeMapRepository(database)
+	checkRepo := repository.NewCheckRepository(database)
+	certRepo := repository.NewCertificateRepository(database)
+	snmpRepo := repository.NewSNMPRepository(database)
+	metricRepo := repository.NewMetricRepository(database)
+	logRepo := repository.NewLogRepository(database)
+	alertRepo := repository.NewAlertRepository(database)
+	notificationRepo := repository.NewNotificationRepository(database)
+	maintenanceRepo := repository.NewMaintenanceRepository(database)
+	escalationRepo := repository.NewEscalationRepository(database)
+	agentRepo := repository.NewAgentRepository(database)
 
 	locationRepo := repository.NewLocationRepository(database)
 	locationUsecase := usecase.NewLocationUsecase(locationRepo)
 	locationHandler := delivery.NewLocationHandler(locationUsecase)
 
-	deviceUsecase := usecase.NewDeviceUsecase(deviceRepo, deviceTypeMapRepo, deviceTypeRepo)
+	hub := events.NewHub()
+	relay := events.NewRelay(hub, sqlDB)
+	deviceUsecase := usecase.NewDeviceUsecase(deviceRepo, deviceTypeMapRepo, deviceTypeRepo, checkRepo, certRepo, metricRepo, snmpRepo, agentRepo, hub)
 	deviceTypeUsecase := usecase.NewDeviceTypeUsecase(deviceTypeRepo)
+	checkUsecase := usecase.NewCheckUsecase(checkRepo, deviceRepo, certRepo, deviceUsecase.Probers)
+	certUsecase := usecase.NewCertificateUsecase(certRepo)
+	metricUsecase := usecase.NewMetricUsecase(metricRepo)
+	logUsecase := usecase.NewLogUsecase(logRepo)
+	maintenanceUsecase := usecase.NewMaintenanceUsecase(maintenanceRepo, deviceTypeMapRepo)
+	reportUsecase := usecase.NewReportUsecase(deviceRepo, logRepo, deviceTypeRepo, deviceTypeMapRepo, locationRepo, maintenanceUsecase)
+	alertUsecase := usecase.NewAlertUsecase(alertRepo, escalationRepo, deviceRepo, deviceTypeMapRepo, locationRepo, certRepo, logRepo)
+	alertUsecase.Suppressor = maintenanceUsecase
+	deviceUsecase.AddListener(alertUsecase)
+	deviceUsecase.Alerts = alertUsecase
+	notificationUsecase := usecase.NewNotificationUsecase(notificationRepo)
+	alertUsecase.AddListener(notificationUsecase)
+	escalationUsecase := usecase.NewEscalationUsecase(escalationRepo, alertRepo, notificationUsecase)
+	escalationUsecase.Suppressor = maintenanceUsecase
+	notificationUsecase.InUse = escalationUsecase
+	alertUsecase.AddListener(escalationUsecase)
+	agentUsecase := usecase.NewAgentUsecase(agentRepo, deviceUsecase)
+	deviceUsecase.Remote = agentUsecase
+	snmpUsecase := usecase.NewSNMPUsecase(snmpRepo, deviceTypeMapRepo)
+	if err := snmpUsecase.SeedBuiltinOIDSets(); err != nil {
+		log.Printf("Error seeding SNMP OID sets: %v", err)
+	}
 
 	deviceHandler := delivery.NewDeviceHandler(deviceUsecase)
 	deviceTypeHandler := delivery.NewDeviceTypeHandler(deviceTypeUsecase)
+	checkHandler := delivery.NewCheckHandler(checkUsecase)
+	certHandler := delivery.NewCertificateHandler(certUsecase)
+	snmpHandler := delivery.NewSNMPHandler(snmpUsecase)
+	metricHandler := delivery.NewMetricHandler(metricUsecase)
+	logHandler := delivery.NewLogHandler(logUsecase)
+	reportHandler := delivery.NewReportHandler(reportUsecase)
+	alertHandler := delivery.NewAlertHandler(alertUsecase)
+	notificationHandler := delivery.NewNotificationHandler(notificationUsecase)
+	maintenanceHandler := delivery.NewMaintenanceHandler(maintenanceUsecase)
+	escalationHandler := delivery.NewEscalationHandler(escalationUsecase)
+	agentHandler := delivery.NewAgentHandler(agentUsecase)
+	wsHandler := delivery.NewWSHandler(deviceUsecase, alertUsecase)
+
+	probeScheduler := scheduler.New(scheduler.ConfigFromEnv(), deviceUsecase.GetAllDevices, deviceUsecase.CheckDevice)
+	deviceUsecase.Guard = probeScheduler
+	elector := leader.New(sqlDB)
+	deviceUsecase.Leader = elector
+	schedulerHandler := delivery.NewSchedulerHandler(probeScheduler, elector)
+	snmpScheduler := scheduler.New(scheduler.LoadConfig("SNMP", scheduler.Config{
+		Name:         "SNMP",
+		Workers:      10,
+		Interval:     time.Minute,
+		CycleTimeout: 30 * time.Second,
+		Tick:         5 * time.Second,
+	}), snmpUsecase.GetPolledDevices, snmpUsecase.PollDevice)
 
 	allowedOrigins := os.Getenv("ALLOWED_ORIGINS")
 
@@ -56,10 +135,12 @@ func main() {
 	r.GET("/devices", deviceHandler.GetAllDevices)
 	r.GET("/sse", deviceHandler.SSE)
 	r.GET("/live", deviceHandler.GetAllLiveDevices) // without sse
+	r.GET("/ws", wsHandler.Serve)
 	r.POST("/devices", deviceHandler.InsertDevice)
 	r.PUT("/devices/:id", deviceHandler.UpdateDevice)
 	r.GET("/devices/:id", deviceHandler.GetDeviceByID)
 	r.DELETE("/devices/:id", deviceHandler.DeleteDevice)
+	r.POST("/devices/:id/check", deviceHandler.CheckDevice)
 
 	r.POST("/devices_types", deviceTypeHandler.CreateDeviceType)
 	r.PUT("/devices_types/:id", deviceTypeHandler.UpdateDeviceType)
@@ -77,24 +158,150 @@ func main() {
 	r.DELETE("/locations/:id", locationHandler.DeleteLocation)
 
 	r.GET("/devices/full", deviceHandler.GetAllDevicesWithTypesAndLocation)
+	r.GET("/devices/tree", deviceHandler.GetDeviceTree)
 	r.GET("/locations/:id/devices", deviceHandler.GetDevicesByLocation)
 
-	// Periodically check device statuses
-	go func() {
-		log.Println("Starting device status update...")
-		for {
-			deviceHandler.Usecase.UpdateDeviceStatus()
-			log.Println("Device status update completed.")
-			time.Sleep(5 * time.Second)
+	r.GET("/devices/:id/checks", checkHandler.GetChecksByDevice)
+	r.POST("/devices/:id/checks", checkHandler.CreateCheck)
+	r.GET("/checks/:id", checkHandler.GetCheckByID)
+	r.PUT("/checks/:id", checkHandler.UpdateCheck)
+	r.DELETE("/checks/:id", checkHandler.DeleteCheck)
+	r.POST("/checks/:id/token", checkHandler.RotateToken)
+	r.POST("/heartbeat/:token", deviceHandler.ReceiveHeartbeat)
+
+	r.GET("/certificates", certHandler.GetExpiringCertificates)
+	r.GET("/devices/:id/certificates", certHandler.GetCertificatesByDevice)
+
+	r.GET("/devices/:id/metrics", metricHandler.GetDeviceMetrics)
+
+	r.GET("/logs", logHandler.GetLogs)
+	r.GET("/devices/:id/logs", logHandler.GetDeviceLogs)
+	r.GET("/reports/availability", reportHandler.GetAvailability)
+
+	r.GET("/alert_rules", alertHandler.GetAllRules)
+	r.POST("/alert_rules", alertHandler.CreateRule)
+	r.GET("/alert_rules/:id", alertHandler.GetRuleByID)
+	r.PUT("/alert_rules/:id", alertHandler.UpdateRule)
+	r.DELETE("/alert_rules/:id", alertHandler.DeleteRule)
+	r.GET("/alerts", alertHandler.GetAlerts)
+	r.GET("/alerts/:id", alertHandler.GetAlertByID)
+	r.POST("/alerts/:id/ack", alertHandler.AcknowledgeAlert)
+	r.GET("/notification_channels", notificationHandler.GetAllChannels)
+	r.POST("/notification_channels", notificationHandler.CreateChannel)
+	r.GET("/notification_channels/:id", notificationHandler.GetChannelByID)
+	r.PUT("/notification_channels/:id", notificationHandler.UpdateChannel)
+	r.DELETE("/notification_channels/:id", notificationHandler.DeleteChannel)
+	r.POST("/notification_channels/:id/test", notificationHandler.TestChannel)
+	r.GET("/notification_deliveries", notificationHandler.GetDeliveries)
+
+	r.GET("/maintenance_windows", maintenanceHandler.GetAllWindows)
+	r.POST("/maintenance_windows", maintenanceHandler.CreateWindow)
+	r.GET("/maintenance_windows/:id", maintenanceHandler.GetWindowByID)
+	r.PUT("/maintenance_windows/:id", maintenanceHandler.UpdateWindow)
+	r.DELETE("/maintenance_windows/:id", maintenanceHandler.DeleteWindow)
+	r.GET("/maintenance_windows/:id/occurrences", maintenanceHandler.GetWindowOccurrences)
+	r.GET("/silences", maintenanceHandler.GetSilences)
+	r.POST("/silences", maintenanceHandler.CreateSilence)
+	r.GET("/silences/:id", maintenanceHandler.GetSilenceByID)
+	r.POST("/silences/:id/expire", maintenanceHandler.ExpireSilence)
+	r.DELETE("/silences/:id", maintenanceHandler.DeleteSilence)
+
+	r.GET("/escalation_policies", escalationHandler.GetAllPolicies)
+	r.POST("/escalation_policies", escalationHandler.CreatePolicy)
+	r.GET("/escalation_policies/:id", escalationHandler.GetPolicyByID)
+	r.PUT("/escalation_policies/:id", escalationHandler.UpdatePolicy)
+	r.DELETE("/escalation_policies/:id", escalationHandler.DeletePolicy)
+	r.GET("/oncall_schedules", escalationHandler.GetAllSchedules)
+	r.POST("/oncall_schedules", escalationHandler.CreateSchedule)
+	r.GET("/oncall_schedules/:id", escalationHandler.GetScheduleByID)
+	r.PUT("/oncall_schedules/:id", escalationHandler.UpdateSchedule)
+	r.DELETE("/oncall_schedules/:id", escalationHandler.DeleteSchedule)
+	r.GET("/oncall_schedules/:id/oncall", escalationHandler.GetOnCall)
+	r.GET("/oncall_schedules/:id/overrides", escalationHandler.GetOverrides)
+	r.POST("/oncall_schedules/:id/overrides", escalationHandler.CreateOverride)
+	r.DELETE("/oncall_overrides/:id", escalationHandler.DeleteOverride)
+
+	r.GET("/agents", agentHandler.GetAllAgents)
+	r.POST("/agents", agentHandler.CreateAgent)
+	r.GET("/agents/:id", agentHandler.GetAgentByID)
+	r.PUT("/agents/:id", agentHandler.UpdateAgent)
+	r.DELETE("/agents/:id", agentHandler.DeleteAgent)
+	r.POST("/agents/:id/token", agentHandler.RotateToken)
+	r.GET("/agents/:id/results", agentHandler.GetAgentResults)
+
+	// API used by agents, authenticated with their token
+	agentAPI := r.Group("/agent", agentHandler.Authenticate)
+	agentAPI.POST("/register", agentHandler.Register)
+	agentAPI.GET("/assignments", agentHandler.GetAssignments)
+	agentAPI.POST("/results", agentHandler.ReportResults)
+
+	r.GET("/devices/:id/snmp", snmpHandler.GetCredential)
+	r.PUT("/devices/:id/snmp", snmpHandler.SaveCredential)
+	r.DELETE("/devices/:id/snmp", snmpHandler.DeleteCredential)
+	r.GET("/devices/:id/snmp/metrics", snmpHandler.GetMetrics)
+	r.GET("/snmp/oid_sets", snmpHandler.GetAllOIDSets)
+	r.POST("/snmp/oid_sets", snmpHandler.CreateOIDSet)
+	r.GET("/snmp/oid_sets/:id", snmpHandler.GetOIDSetByID)
+	r.PUT("/snmp/oid_sets/:id", snmpHandler.UpdateOIDSet)
+	r.DELETE("/snmp/oid_sets/:id", snmpHandler.DeleteOIDSet)
+	r.GET("/devices_types/:id/oid_sets", snmpHandler.GetTypeOIDSets)
+	r.PUT("/devices_types/:id/oid_sets", snmpHandler.SetTypeOIDSets)
+
+	r.GET("/scheduler/stats", schedulerHandler.GetStats)
+	r.GET("/scheduler/leader", schedulerHandler.GetLeader)
+
+	// Share events with the other instances
+	go relay.Run(context.Background())
+
+	// Evaluate alert rules against check results
+	go alertUsecase.Run(context.Background())
+
+	// Only the elected instance probes devices, polls SNMP, escalates
+	// alerts and prunes metrics
+	go elector.Run(context.Background(), func(ctx context.Context) {
+		deviceUsecase.Tracker.Reset()
+		var wg sync.WaitGroup
+		for _, run := range []func(context.Context){
+			// Periodically check device statuses
+			func(ctx context.Context) {
+				log.Println("Starting device status update...")
+				probeScheduler.Run(ctx)
+			},
+			// Periodically poll SNMP metrics
+			snmpScheduler.Run,
+			// Escalate unacknowledged alerts
+			escalationUsecase.Run,
+			// Drop metrics past their retention period
+			func(ctx context.Context) {
+				ticker := time.NewTicker(time.Hour)
+				defer ticker.Stop()
+				for {
+					if n, err := metricUsecase.PruneMetrics(); err != nil {
+						log.Printf("Error pruning metrics: %v", err)
+					} else if n > 0 {
+						log.Printf("Pruned %d metrics", n)
+					}
+					if n, err := snmpUsecase.PruneMetrics(); err != nil {
+						log.Printf("Error pruning SNMP metrics: %v", err)
+					} else if n > 0 {
+						log.Printf("Pruned %d SNMP metrics", n)
+					}
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+					}
+				}
+			},
+		} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				run(ctx)
+			}()
 		}
-	}()
-
-	// Start a goroutine to process broadcast messages
-	// go func() {
-	// 	for message := range usecase.BroadcastChannel {
-	// 		log.Printf("Broadcast message: %+v", message)
-	// 	}
-	// }()
+		wg.Wait()
+	})
 
 	// Start server
 	r.Run(":8082")